@@ -0,0 +1,327 @@
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+// DefaultBaseURL est l'URL de l'API football-data.org (variable `{{url}}`
+// de la collection Postman `data.json`).
+const DefaultBaseURL = "https://api.football-data.org"
+
+// DefaultCompetition est la compétition utilisée quand aucune n'est configurée.
+const DefaultCompetition = "PL"
+
+// Client interroge l'API v4 de football-data.org.
+// La clé est envoyée dans l'en-tête `X-Auth-Token`, comme décrit dans
+// l'authentification de la collection Postman.
+type Client struct {
+	BaseURL     string
+	APIKey      string
+	Competition string
+	HTTPClient  *http.Client
+}
+
+// Area décrit la zone géographique d'une compétition.
+type Area struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Code string `json:"code"`
+	Flag string `json:"flag,omitempty"`
+}
+
+// Competition décrit une compétition renvoyée par `/v4/competitions`.
+type Competition struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Code   string `json:"code"`
+	Type   string `json:"type"`
+	Emblem string `json:"emblem,omitempty"`
+	Area   Area   `json:"area"`
+}
+
+// TeamRef est la référence abrégée d'une équipe dans un match ou un
+// classement.
+type TeamRef struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	ShortName string `json:"shortName"`
+	TLA       string `json:"tla"`
+	Crest     string `json:"crest,omitempty"`
+}
+
+// tableEntry est une ligne d'un classement de `/v4/competitions/{code}/standings`.
+type tableEntry struct {
+	Position       int     `json:"position"`
+	Team           TeamRef `json:"team"`
+	PlayedGames    int     `json:"playedGames"`
+	Won            int     `json:"won"`
+	Draw           int     `json:"draw"`
+	Lost           int     `json:"lost"`
+	Points         int     `json:"points"`
+	GoalsFor       int     `json:"goalsFor"`
+	GoalsAgainst   int     `json:"goalsAgainst"`
+	GoalDifference int     `json:"goalDifference"`
+}
+
+// standing est un tableau de classement (total, domicile ou extérieur).
+type standing struct {
+	Stage string       `json:"stage"`
+	Type  string       `json:"type"`
+	Group string       `json:"group,omitempty"`
+	Table []tableEntry `json:"table"`
+}
+
+// team est la représentation d'une équipe dans `/v4/competitions/{code}/teams`.
+type team struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	ShortName string `json:"shortName"`
+	TLA       string `json:"tla"`
+	Crest     string `json:"crest"`
+	Website   string `json:"website"`
+	Founded   int    `json:"founded"`
+	Venue     string `json:"venue"`
+	Address   string `json:"address"`
+	Colors    string `json:"clubColors"`
+	Area      Area   `json:"area"`
+	// RunningCompetitions liste les compétitions en cours du club.
+	RunningCompetitions []Competition `json:"runningCompetitions"`
+	Squad               []squadMember `json:"squad"`
+}
+
+// squadMember est un joueur de l'effectif d'une équipe.
+type squadMember struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Position    string `json:"position"`
+	DateOfBirth string `json:"dateOfBirth"`
+	Nationality string `json:"nationality"`
+	ShirtNumber int    `json:"shirtNumber"`
+}
+
+// competitionName renvoie le nom de la compétition `code` parmi celles
+// que dispute l'équipe, ou `code` s'il n'y figure pas.
+func (t team) competitionName(code string) string {
+	for _, comp := range t.RunningCompetitions {
+		if comp.Code == code {
+			return comp.Name
+		}
+	}
+	return code
+}
+
+// match est la représentation d'un match dans `/v4/competitions/{code}/matches`.
+type match struct {
+	ID          int       `json:"id"`
+	UtcDate     time.Time `json:"utcDate"`
+	Status      string    `json:"status"`
+	Matchday    int       `json:"matchday"`
+	Competition struct {
+		Code string `json:"code"`
+	} `json:"competition"`
+	HomeTeam TeamRef `json:"homeTeam"`
+	AwayTeam TeamRef `json:"awayTeam"`
+	Score    struct {
+		FullTime struct {
+			Home *int `json:"home"`
+			Away *int `json:"away"`
+		} `json:"fullTime"`
+	} `json:"score"`
+}
+
+// New crée un client pour la clé `apiKey` avec les valeurs par défaut
+// (URL officielle, compétition "PL", délai d'attente de 10 secondes).
+func New(apiKey string) *Client {
+	return &Client{
+		BaseURL:     DefaultBaseURL,
+		APIKey:      apiKey,
+		Competition: DefaultCompetition,
+		HTTPClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// getJSON exécute une requête GET sur `path` (relatif à `/v4`) et
+// désérialise la réponse JSON dans `out`. Un statut différent de 200
+// est renvoyé comme erreur avec le début du corps de la réponse.
+func (c *Client) getJSON(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/v4"+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Auth-Token", c.APIKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("football-data %s: status %d: %s", path, resp.StatusCode, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// teams renvoie les équipes de la compétition configurée, avec leur effectif.
+func (c *Client) teams(ctx context.Context) ([]team, error) {
+	var payload struct {
+		Teams []team `json:"teams"`
+	}
+	if err := c.getJSON(ctx, "/competitions/"+url.PathEscape(c.Competition)+"/teams", &payload); err != nil {
+		return nil, err
+	}
+	return payload.Teams, nil
+}
+
+// Teams renvoie les clubs de la compétition configurée, convertis en `models.Club`.
+// football-data.org ne fournit pas la ville séparément de l'adresse :
+// `City` reste vide.
+func (c *Client) Teams(ctx context.Context) ([]models.Club, error) {
+	teams, err := c.teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clubs := make([]models.Club, 0, len(teams))
+	for _, t := range teams {
+		clubs = append(clubs, models.Club{
+			ID:          t.ID,
+			Name:        t.Name,
+			ShortName:   t.ShortName,
+			TLA:         t.TLA,
+			Website:     t.Website,
+			Founded:     t.Founded,
+			Venue:       t.Venue,
+			CrestURL:    t.Crest,
+			Country:     t.Area.Name,
+			Address:     t.Address,
+			Colors:      t.Colors,
+			Competition: t.competitionName(c.Competition),
+		})
+	}
+	return clubs, nil
+}
+
+// Squads renvoie les effectifs des clubs de la compétition configurée,
+// convertis en `models.Player` : leurs `ClubID` sont les IDs de clubs
+// renvoyés par Teams.
+func (c *Client) Squads(ctx context.Context) ([]models.Player, error) {
+	teams, err := c.teams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	players := []models.Player{}
+	for _, t := range teams {
+		for _, p := range t.Squad {
+			players = append(players, models.Player{
+				ID:          p.ID,
+				ClubID:      t.ID,
+				Name:        p.Name,
+				Position:    p.Position,
+				Nationality: p.Nationality,
+				DateOfBirth: p.DateOfBirth,
+				ShirtNumber: p.ShirtNumber,
+			})
+		}
+	}
+	return players, nil
+}
+
+// Competitions renvoie la liste des compétitions accessibles avec la clé.
+func (c *Client) Competitions(ctx context.Context) ([]Competition, error) {
+	var payload struct {
+		Competitions []Competition `json:"competitions"`
+	}
+	if err := c.getJSON(ctx, "/competitions", &payload); err != nil {
+		return nil, err
+	}
+	return payload.Competitions, nil
+}
+
+// Standings renvoie le classement général (tableaux "TOTAL", un par
+// groupe le cas échéant) de la compétition configurée, converti en `models.CompetitionStandings`. Les
+// clubs n'y portent que leur ID, leurs noms, leur TLA et leur blason.
+func (c *Client) Standings(ctx context.Context) ([]models.CompetitionStandings, error) {
+	var payload struct {
+		Competition struct {
+			Code string `json:"code"`
+		} `json:"competition"`
+		Standings []standing `json:"standings"`
+	}
+	if err := c.getJSON(ctx, "/competitions/"+url.PathEscape(c.Competition)+"/standings", &payload); err != nil {
+		return nil, err
+	}
+	code := payload.Competition.Code
+	if code == "" {
+		code = c.Competition
+	}
+	result := []models.CompetitionStandings{}
+	for _, st := range payload.Standings {
+		if st.Type != "TOTAL" {
+			continue
+		}
+		rows := make([]models.StandingRow, 0, len(st.Table))
+		for _, e := range st.Table {
+			rows = append(rows, models.StandingRow{
+				Position: e.Position,
+				Club: models.Club{
+					ID:        e.Team.ID,
+					Name:      e.Team.Name,
+					ShortName: e.Team.ShortName,
+					TLA:       e.Team.TLA,
+					CrestURL:  e.Team.Crest,
+				},
+				Played:         e.PlayedGames,
+				Won:            e.Won,
+				Drawn:          e.Draw,
+				Lost:           e.Lost,
+				GoalsFor:       e.GoalsFor,
+				GoalsAgainst:   e.GoalsAgainst,
+				GoalDifference: e.GoalDifference,
+				Points:         e.Points,
+			})
+		}
+		result = append(result, models.CompetitionStandings{
+			Competition: code,
+			Group:       st.Group,
+			Table:       rows,
+		})
+	}
+	return result, nil
+}
+
+// Matches renvoie les matchs de la saison en cours de la compétition
+// configurée, convertis en `models.Match` et triés par date.
+func (c *Client) Matches(ctx context.Context) ([]models.Match, error) {
+	var payload struct {
+		Matches []match `json:"matches"`
+	}
+	if err := c.getJSON(ctx, "/competitions/"+url.PathEscape(c.Competition)+"/matches", &payload); err != nil {
+		return nil, err
+	}
+	matches := make([]models.Match, 0, len(payload.Matches))
+	for _, m := range payload.Matches {
+		matches = append(matches, models.Match{
+			ID:          m.ID,
+			Competition: m.Competition.Code,
+			Matchday:    m.Matchday,
+			UtcDate:     m.UtcDate,
+			Status:      m.Status,
+			HomeTeamID:  m.HomeTeam.ID,
+			AwayTeamID:  m.AwayTeam.ID,
+			HomeScore:   m.Score.FullTime.Home,
+			AwayScore:   m.Score.FullTime.Away,
+		})
+	}
+	models.SortMatches(matches)
+	return matches, nil
+}
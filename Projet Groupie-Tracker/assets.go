@@ -0,0 +1,29 @@
+// Package groupietracker embarque dans le binaire les templates HTML et
+// les fichiers statiques, pour que le serveur fonctionne quel que soit
+// le répertoire depuis lequel il est lancé.
+package groupietracker
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed template/*.html
+var templateFiles embed.FS
+
+//go:embed data/static
+var staticFiles embed.FS
+
+// Templates contient les fichiers de `template/` (ex: "index.html").
+var Templates fs.FS = mustSub(templateFiles, "template")
+
+// Static contient les fichiers de `data/static/`, servis sous `/static/`.
+var Static fs.FS = mustSub(staticFiles, "data/static")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
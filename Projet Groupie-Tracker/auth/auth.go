@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SessionTTL est la durée de vie d'une session utilisateur.
+const SessionTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidCredentials est renvoyée quand l'e-mail ou le mot de passe est incorrect.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrEmailTaken est renvoyée à l'inscription si l'e-mail est déjà utilisé.
+	ErrEmailTaken = errors.New("email already registered")
+	// ErrInvalidEmail est renvoyée si l'e-mail n'a pas un format valide.
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrWeakPassword est renvoyée si le mot de passe est trop court.
+	ErrWeakPassword = errors.New("password must be at least 8 characters")
+	// ErrNoSession est renvoyée quand le jeton de session est inconnu ou expiré.
+	ErrNoSession = errors.New("session not found or expired")
+)
+
+// User est un compte utilisateur.
+type User struct {
+	ID        int64
+	Email     string
+	CreatedAt time.Time
+}
+
+// Store persiste les comptes, les sessions et les favoris dans SQLite.
+type Store struct {
+	db *sql.DB
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	email         TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	created_at    TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS sessions (
+	token_hash TEXT PRIMARY KEY,
+	user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS sessions_expires ON sessions(expires_at);
+CREATE TABLE IF NOT EXISTS favorites (
+	user_id  INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	club_id  TEXT NOT NULL,
+	added_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, club_id)
+);`
+
+// Open ouvre (ou crée) la base SQLite `path` et applique le schéma.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close ferme la base de données.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// normalizeEmail met l'e-mail en minuscules et vérifie son format minimal.
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.Index(email, "@")
+	if at < 1 || at == len(email)-1 || strings.ContainsAny(email, " \t\r\n") {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// CreateUser inscrit un nouvel utilisateur avec un mot de passe haché.
+func (s *Store) CreateUser(email, password string) (*User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if len(password) < 8 {
+		return nil, ErrWeakPassword
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	res, err := s.db.Exec(`INSERT INTO users (email, password_hash, created_at) VALUES (?, ?, ?)`, email, hash, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &User{ID: id, Email: email, CreatedAt: now}, nil
+}
+
+// dummyHash est comparé au mot de passe quand l'e-mail est inconnu, pour
+// que la réponse prenne le même temps que pour un compte existant et ne
+// révèle pas quels e-mails sont inscrits.
+var dummyHash = sync.OnceValue(func() string {
+	hash, err := HashPassword("dummy password")
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// Authenticate vérifie l'e-mail et le mot de passe et renvoie l'utilisateur.
+func (s *Store) Authenticate(email, password string) (*User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		CheckPassword(dummyHash(), password)
+		return nil, ErrInvalidCredentials
+	}
+	var u User
+	var hash string
+	err = s.db.QueryRow(`SELECT id, email, password_hash, created_at FROM users WHERE email = ?`, email).
+		Scan(&u.ID, &u.Email, &hash, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		CheckPassword(dummyHash(), password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CheckPassword(hash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return &u, nil
+}
+
+// hashToken renvoie l'empreinte stockée en base pour un jeton de session,
+// afin qu'une fuite de la base ne permette pas de réutiliser les sessions.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSession ouvre une session pour `userID` et renvoie le jeton à
+// placer dans le cookie de session. Les sessions expirées sont purgées
+// au passage (voir PurgeSessions).
+func (s *Store) CreateSession(userID int64) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	if err := s.PurgeSessions(); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	_, err := s.db.Exec(`INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		hashToken(token), userID, now, now.Add(SessionTTL))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// UserBySession renvoie l'utilisateur associé à un jeton de session valide.
+func (s *Store) UserBySession(token string) (*User, error) {
+	var u User
+	err := s.db.QueryRow(`
+		SELECT u.id, u.email, u.created_at
+		FROM sessions s JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND s.expires_at > ?`, hashToken(token), time.Now().UTC()).
+		Scan(&u.ID, &u.Email, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// PurgeSessions supprime les sessions expirées, que UserBySession ignore
+// déjà mais qui resteraient sinon indéfiniment en base.
+func (s *Store) PurgeSessions() error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE expires_at <= ?`, time.Now().UTC())
+	return err
+}
+
+// DeleteSession ferme la session correspondant au jeton.
+func (s *Store) DeleteSession(token string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE token_hash = ?`, hashToken(token))
+	return err
+}
+
+// Favorites renvoie les IDs des clubs favoris de l'utilisateur, dans
+// l'ordre où ils ont été ajoutés.
+func (s *Store) Favorites(userID int64) ([]string, error) {
+	rows, err := s.db.Query(`SELECT club_id FROM favorites WHERE user_id = ? ORDER BY added_at, rowid`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// AddFavorites ajoute des clubs aux favoris de l'utilisateur ; les IDs
+// déjà présents sont ignorés. Sert aussi à migrer les favoris du cookie.
+func (s *Store) AddFavorites(userID int64, clubIDs ...string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	now := time.Now().UTC()
+	for _, id := range clubIDs {
+		if id == "" {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO favorites (user_id, club_id, added_at) VALUES (?, ?, ?)`, userID, id, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveFavorite retire un club des favoris de l'utilisateur.
+func (s *Store) RemoveFavorite(userID int64, clubID string) error {
+	_, err := s.db.Exec(`DELETE FROM favorites WHERE user_id = ? AND club_id = ?`, userID, clubID)
+	return err
+}
+
+// ClearFavorites supprime tous les favoris de l'utilisateur.
+func (s *Store) ClearFavorites(userID int64) error {
+	_, err := s.db.Exec(`DELETE FROM favorites WHERE user_id = ?`, userID)
+	return err
+}
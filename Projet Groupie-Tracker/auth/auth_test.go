@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "auth.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := openTestStore(t)
+	if _, err := s.CreateUser("Fan@Example.com", "correct horse"); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  error
+	}{
+		{"valid", "fan@example.com", "correct horse", nil},
+		{"email case and spaces ignored", "  FAN@example.COM ", "correct horse", nil},
+		{"wrong password", "fan@example.com", "wrong horse", ErrInvalidCredentials},
+		{"unknown email", "other@example.com", "correct horse", ErrInvalidCredentials},
+		{"malformed email", "not-an-email", "correct horse", ErrInvalidCredentials},
+		{"empty password", "fan@example.com", "", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := s.Authenticate(tt.email, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && u.Email != "fan@example.com" {
+				t.Errorf("email = %q, want normalized email", u.Email)
+			}
+		})
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	s := openTestStore(t)
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  error
+	}{
+		{"valid", "a@example.com", "password1", nil},
+		{"taken", "A@example.com", "password2", ErrEmailTaken},
+		{"invalid email", "a@", "password1", ErrInvalidEmail},
+		{"weak password", "b@example.com", "short", ErrWeakPassword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.CreateUser(tt.email, tt.password); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSessions(t *testing.T) {
+	s := openTestStore(t)
+	u, err := s.CreateUser("fan@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	token, err := s.CreateSession(u.ID)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	// Une session expirée, insérée directement, doit être ignorée puis purgée.
+	past := time.Now().UTC().Add(-time.Hour)
+	if _, err := s.db.Exec(`INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		hashToken("expired"), u.ID, past.Add(-SessionTTL), past); err != nil {
+		t.Fatalf("insert expired session: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", token, nil},
+		{"unknown", "unknown", ErrNoSession},
+		{"expired", "expired", ErrNoSession},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.UserBySession(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.ID != u.ID {
+				t.Errorf("user %d, want %d", got.ID, u.ID)
+			}
+		})
+	}
+
+	if _, err := s.CreateSession(u.ID); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	var n int
+	s.db.QueryRow(`SELECT COUNT(*) FROM sessions WHERE token_hash = ?`, hashToken("expired")).Scan(&n)
+	if n != 0 {
+		t.Error("expired session not purged on login")
+	}
+
+	if err := s.DeleteSession(token); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+	if _, err := s.UserBySession(token); !errors.Is(err, ErrNoSession) {
+		t.Errorf("after DeleteSession: err = %v, want ErrNoSession", err)
+	}
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+)
+
+// SessionCookie est le nom du cookie portant le jeton de session.
+const SessionCookie = "session"
+
+type contextKey struct{}
+
+// Middleware lit le cookie de session, charge l'utilisateur correspondant
+// et le place dans le contexte de la requête (voir UserFromContext).
+// Un cookie inconnu ou expiré est supprimé ; la requête continue alors
+// en anonyme.
+func Middleware(store *Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(SessionCookie)
+		if err != nil || cookie.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		user, err := store.UserBySession(cookie.Value)
+		if err != nil {
+			if err != ErrNoSession {
+				log.Printf("session lookup failed: %v", err)
+			}
+			ClearSessionCookie(w, r)
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, user)))
+	})
+}
+
+// UserFromContext renvoie l'utilisateur connecté, ou nil en anonyme.
+func UserFromContext(ctx context.Context) *User {
+	u, _ := ctx.Value(contextKey{}).(*User)
+	return u
+}
+
+// SetSessionCookie écrit le cookie de session (HttpOnly, SameSite=Lax,
+// et Secure quand la requête `r` arrive en HTTPS).
+func SetSessionCookie(w http.ResponseWriter, r *http.Request, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearSessionCookie supprime le cookie de session côté client.
+func ClearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,350 @@
+// Package config rassemble la configuration du serveur. Chaque valeur a
+// un défaut, peut être surchargée par une variable d'environnement, puis
+// par un flag de la ligne de commande (le flag l'emporte).
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"groupie_tracker/apiclient"
+	"groupie_tracker/live"
+	"groupie_tracker/mailer"
+	"groupie_tracker/models"
+)
+
+// Config décrit le serveur : adresse d'écoute, fichiers de données,
+// accès à l'API football-data.org, caches, délais et TLS.
+type Config struct {
+	// Addr est l'adresse d'écoute (`ADDR`, ou `PORT` seul ; `-addr`).
+	Addr string
+	// Dev active le mode développement (`GROUPIE_DEV=1` ; `-dev`) : les
+	// templates et fichiers statiques sont lus sur le disque, depuis
+	// TemplateDir et StaticDir, au lieu des copies embarquées dans le binaire.
+	Dev         bool
+	TemplateDir string
+	StaticDir   string
+	// ClubsFile, SquadsFile et MatchesFile sont les fichiers JSON locaux.
+	ClubsFile   string
+	SquadsFile  string
+	MatchesFile string
+	// CollectionFile est la collection Postman de l'API affichée par
+	// `/api-explorer` (`COLLECTION_FILE` ; `-collection`).
+	CollectionFile string
+	// BaseURL est l'URL publique du site, utilisée par `/sitemap.xml` et
+	// les URL canoniques (`BASE_URL` ; `-base-url`). Vide, elle est
+	// déduite de chaque requête.
+	BaseURL string
+	// DatabasePath est la base SQLite (`GROUPIE_DB` ; `-db`).
+	DatabasePath string
+	// ClubsBackend vaut "sqlite" pour servir les clubs depuis la base.
+	ClubsBackend string
+
+	// APIKey, APIURL et Competition configurent football-data.org ;
+	// sans clé, seuls les fichiers locaux sont utilisés.
+	APIKey      string
+	APIURL      string
+	Competition string
+
+	// CacheTTL est la durée de vie des caches de données.
+	CacheTTL time.Duration
+
+	// Délais du serveur HTTP et durée accordée aux requêtes en cours
+	// pour se terminer à l'arrêt.
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+
+	// AdminEmails liste les comptes administrateurs (`ADMIN_EMAILS`, séparés
+	// par des virgules ; `-admin-emails`). AdminUser et AdminPassword
+	// (`ADMIN_USER`, `ADMIN_PASSWORD`, variables uniquement) activent en
+	// plus l'authentification HTTP Basic sur `/admin`.
+	AdminEmails   []string
+	AdminUser     string
+	AdminPassword string
+	// MetricsToken, s'il est défini, donne accès à `/metrics` avec l'en-tête
+	// `Authorization: Bearer <jeton>` (`METRICS_TOKEN`, variable
+	// uniquement) ; sinon seuls les administrateurs y ont accès.
+	MetricsToken string
+
+	// ContactsBackend vaut "sqlite" pour enregistrer les messages du
+	// formulaire de contact dans la base, sinon ils sont écrits dans
+	// ContactsFile (`CONTACTS_BACKEND`, `CONTACTS_FILE`).
+	ContactsBackend string
+	ContactsFile    string
+
+	// SMTPAddr ("hôte:port"), SMTPUser, SMTPPassword et SMTPFrom
+	// configurent l'envoi des messages de contact par e-mail à
+	// ContactEmail (`SMTP_ADDR`, `SMTP_USER`, `SMTP_PASSWORD`, `SMTP_FROM`,
+	// `CONTACT_EMAIL`). Sans SMTPAddr ou ContactEmail, rien n'est envoyé.
+	SMTPAddr     string
+	SMTPUser     string
+	SMTPPassword string
+	SMTPFrom     string
+	ContactEmail string
+
+	// LiveInterval est l'intervalle de lecture des matchs pour les mises
+	// à jour en direct de `/events` (`LIVE_INTERVAL` ; `-live-interval`).
+	LiveInterval time.Duration
+
+	// CrestCacheDir est le répertoire du cache des blasons redimensionnés
+	// et CrestCacheMB sa taille maximale en mégaoctets (`CREST_CACHE_DIR`,
+	// `CREST_CACHE_MB` ; `-crest-cache`, `-crest-cache-mb`).
+	CrestCacheDir string
+	CrestCacheMB  int
+
+	// TLSCert et TLSKey activent HTTPS quand les deux sont renseignés.
+	TLSCert string
+	TLSKey  string
+}
+
+// Default renvoie la configuration par défaut, sans lire l'environnement.
+func Default() Config {
+	return Config{
+		Addr:            ":8080",
+		TemplateDir:     "template",
+		StaticDir:       "data/static",
+		DatabasePath:    "data/groupie.db",
+		ClubsFile:       "data/clubs.json",
+		SquadsFile:      "data/squads.json",
+		MatchesFile:     "data/matches.json",
+		CollectionFile:  "data.json",
+		Competition:     apiclient.DefaultCompetition,
+		APIURL:          apiclient.DefaultBaseURL,
+		CacheTTL:        models.DefaultClubTTL,
+		ReadTimeout:     10 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     120 * time.Second,
+		ShutdownTimeout: 15 * time.Second,
+		AdminUser:       "admin",
+		ContactsFile:    "data/contacts.json",
+		LiveInterval:    live.DefaultInterval,
+		CrestCacheDir:   "data/cache/crests",
+		CrestCacheMB:    20,
+	}
+}
+
+// Load construit la configuration : valeurs par défaut, puis variables
+// d'environnement, puis flags de `args` (sans le nom du programme).
+// Une durée invalide ou un couple TLS incomplet est une erreur.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	if err := cfg.fromEnv(); err != nil {
+		return nil, err
+	}
+
+	fs := flag.NewFlagSet("groupie-tracker", flag.ContinueOnError)
+	fs.StringVar(&cfg.Addr, "addr", cfg.Addr, "adresse d'écoute (ADDR, PORT)")
+	fs.BoolVar(&cfg.Dev, "dev", cfg.Dev, "lire templates et fichiers statiques sur le disque (GROUPIE_DEV)")
+	fs.StringVar(&cfg.TemplateDir, "templates", cfg.TemplateDir, "répertoire des templates en mode dev (TEMPLATE_DIR)")
+	fs.StringVar(&cfg.StaticDir, "static", cfg.StaticDir, "répertoire des fichiers statiques en mode dev (STATIC_DIR)")
+	fs.StringVar(&cfg.ClubsFile, "clubs", cfg.ClubsFile, "fichier JSON des clubs (CLUBS_FILE)")
+	fs.StringVar(&cfg.SquadsFile, "squads", cfg.SquadsFile, "fichier JSON des effectifs (SQUADS_FILE)")
+	fs.StringVar(&cfg.MatchesFile, "matches", cfg.MatchesFile, "fichier JSON des matchs (MATCHES_FILE)")
+	fs.StringVar(&cfg.CollectionFile, "collection", cfg.CollectionFile, "collection Postman de l'API (COLLECTION_FILE)")
+	fs.StringVar(&cfg.BaseURL, "base-url", cfg.BaseURL, "URL publique du site (BASE_URL)")
+	fs.StringVar(&cfg.DatabasePath, "db", cfg.DatabasePath, "base SQLite (GROUPIE_DB)")
+	fs.StringVar(&cfg.ClubsBackend, "clubs-backend", cfg.ClubsBackend, `"sqlite" pour lire les clubs en base (CLUBS_BACKEND)`)
+	fs.StringVar(&cfg.APIKey, "api-key", cfg.APIKey, "clé football-data.org (FOOTBALL_DATA_API_KEY)")
+	fs.StringVar(&cfg.APIURL, "api-url", cfg.APIURL, "URL de l'API (FOOTBALL_DATA_URL)")
+	fs.StringVar(&cfg.Competition, "competition", cfg.Competition, "code de la compétition (FOOTBALL_DATA_COMPETITION)")
+	fs.DurationVar(&cfg.CacheTTL, "cache-ttl", cfg.CacheTTL, "durée des caches de données (CLUBS_CACHE_TTL)")
+	fs.DurationVar(&cfg.ReadTimeout, "read-timeout", cfg.ReadTimeout, "délai de lecture d'une requête (READ_TIMEOUT)")
+	fs.DurationVar(&cfg.WriteTimeout, "write-timeout", cfg.WriteTimeout, "délai d'écriture d'une réponse (WRITE_TIMEOUT)")
+	fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", cfg.IdleTimeout, "durée des connexions keep-alive inactives (IDLE_TIMEOUT)")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "délai accordé aux requêtes en cours à l'arrêt (SHUTDOWN_TIMEOUT)")
+	fs.Func("admin-emails", "e-mails des administrateurs, séparés par des virgules (ADMIN_EMAILS)", func(v string) error {
+		cfg.AdminEmails = splitList(v)
+		return nil
+	})
+	fs.StringVar(&cfg.ContactsBackend, "contacts-backend", cfg.ContactsBackend, `"sqlite" pour enregistrer les messages de contact en base (CONTACTS_BACKEND)`)
+	fs.StringVar(&cfg.ContactsFile, "contacts", cfg.ContactsFile, "fichier JSON des messages de contact (CONTACTS_FILE)")
+	fs.StringVar(&cfg.SMTPAddr, "smtp-addr", cfg.SMTPAddr, "serveur SMTP hôte:port (SMTP_ADDR)")
+	fs.StringVar(&cfg.SMTPFrom, "smtp-from", cfg.SMTPFrom, "expéditeur des e-mails (SMTP_FROM)")
+	fs.StringVar(&cfg.ContactEmail, "contact-email", cfg.ContactEmail, "destinataire des messages de contact (CONTACT_EMAIL)")
+	fs.DurationVar(&cfg.LiveInterval, "live-interval", cfg.LiveInterval, "intervalle de lecture des matchs pour /events (LIVE_INTERVAL)")
+	fs.StringVar(&cfg.CrestCacheDir, "crest-cache", cfg.CrestCacheDir, "répertoire du cache des blasons (CREST_CACHE_DIR)")
+	fs.IntVar(&cfg.CrestCacheMB, "crest-cache-mb", cfg.CrestCacheMB, "taille maximale du cache des blasons en Mo (CREST_CACHE_MB)")
+	fs.StringVar(&cfg.TLSCert, "tls-cert", cfg.TLSCert, "certificat TLS (TLS_CERT_FILE)")
+	fs.StringVar(&cfg.TLSKey, "tls-key", cfg.TLSKey, "clé privée TLS (TLS_KEY_FILE)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// fromEnv applique les variables d'environnement définies.
+func (c *Config) fromEnv() error {
+	if port := os.Getenv("PORT"); port != "" {
+		c.Addr = ":" + port
+	}
+	setString(&c.Addr, "ADDR")
+	if v := os.Getenv("GROUPIE_DEV"); v != "" {
+		dev, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid GROUPIE_DEV %q: %w", v, err)
+		}
+		c.Dev = dev
+	}
+	setString(&c.TemplateDir, "TEMPLATE_DIR")
+	setString(&c.StaticDir, "STATIC_DIR")
+	setString(&c.ClubsFile, "CLUBS_FILE")
+	setString(&c.SquadsFile, "SQUADS_FILE")
+	setString(&c.MatchesFile, "MATCHES_FILE")
+	setString(&c.CollectionFile, "COLLECTION_FILE")
+	setString(&c.BaseURL, "BASE_URL")
+	setString(&c.DatabasePath, "GROUPIE_DB")
+	setString(&c.ClubsBackend, "CLUBS_BACKEND")
+	setString(&c.APIKey, "FOOTBALL_DATA_API_KEY")
+	setString(&c.APIURL, "FOOTBALL_DATA_URL")
+	setString(&c.Competition, "FOOTBALL_DATA_COMPETITION")
+	if v := os.Getenv("ADMIN_EMAILS"); v != "" {
+		c.AdminEmails = splitList(v)
+	}
+	setString(&c.AdminUser, "ADMIN_USER")
+	setString(&c.AdminPassword, "ADMIN_PASSWORD")
+	setString(&c.MetricsToken, "METRICS_TOKEN")
+	setString(&c.ContactsBackend, "CONTACTS_BACKEND")
+	setString(&c.ContactsFile, "CONTACTS_FILE")
+	setString(&c.SMTPAddr, "SMTP_ADDR")
+	setString(&c.SMTPUser, "SMTP_USER")
+	setString(&c.SMTPPassword, "SMTP_PASSWORD")
+	setString(&c.SMTPFrom, "SMTP_FROM")
+	setString(&c.ContactEmail, "CONTACT_EMAIL")
+	setString(&c.CrestCacheDir, "CREST_CACHE_DIR")
+	if err := setInt(&c.CrestCacheMB, "CREST_CACHE_MB"); err != nil {
+		return err
+	}
+	setString(&c.TLSCert, "TLS_CERT_FILE")
+	setString(&c.TLSKey, "TLS_KEY_FILE")
+	for name, d := range map[string]*time.Duration{
+		"CLUBS_CACHE_TTL":  &c.CacheTTL,
+		"READ_TIMEOUT":     &c.ReadTimeout,
+		"WRITE_TIMEOUT":    &c.WriteTimeout,
+		"IDLE_TIMEOUT":     &c.IdleTimeout,
+		"SHUTDOWN_TIMEOUT": &c.ShutdownTimeout,
+		"LIVE_INTERVAL":    &c.LiveInterval,
+	} {
+		if err := setDuration(d, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setString remplace `*dst` par la variable `name` si elle est définie.
+func setString(dst *string, name string) {
+	if v := os.Getenv(name); v != "" {
+		*dst = v
+	}
+}
+
+// splitList découpe une liste séparée par des virgules en ignorant les
+// éléments vides.
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// setInt remplace `*dst` par l'entier de la variable `name`.
+func setInt(dst *int, name string) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, v, err)
+	}
+	*dst = n
+	return nil
+}
+
+// setDuration remplace `*dst` par la durée de la variable `name` (ex: "30s").
+func setDuration(dst *time.Duration, name string) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, v, err)
+	}
+	*dst = d
+	return nil
+}
+
+// Validate vérifie la cohérence de la configuration.
+func (c *Config) Validate() error {
+	if c.Addr == "" {
+		return errors.New("listen address must not be empty")
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return errors.New("TLS requires both a certificate and a key")
+	}
+	if c.CacheTTL < 0 || c.ReadTimeout < 0 || c.WriteTimeout < 0 || c.IdleTimeout < 0 || c.ShutdownTimeout < 0 || c.LiveInterval < 0 {
+		return errors.New("durations must not be negative")
+	}
+	if c.CrestCacheMB < 0 {
+		return errors.New("crest cache size must not be negative")
+	}
+	if c.BaseURL != "" && !strings.HasPrefix(c.BaseURL, "http://") && !strings.HasPrefix(c.BaseURL, "https://") {
+		return fmt.Errorf("invalid base URL %q: must start with http:// or https://", c.BaseURL)
+	}
+	return nil
+}
+
+// TLSEnabled indique si le serveur doit écouter en HTTPS.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCert != "" && c.TLSKey != ""
+}
+
+// Mailer renvoie l'expéditeur des messages de contact, ou nil si SMTPAddr
+// ou ContactEmail n'est pas défini. Sans SMTPFrom, les e-mails sont
+// envoyés depuis ContactEmail.
+func (c *Config) Mailer() *mailer.Mailer {
+	if c.SMTPAddr == "" || c.ContactEmail == "" {
+		return nil
+	}
+	from := c.SMTPFrom
+	if from == "" {
+		from = c.ContactEmail
+	}
+	return &mailer.Mailer{
+		Addr:     c.SMTPAddr,
+		Username: c.SMTPUser,
+		Password: c.SMTPPassword,
+		From:     from,
+		To:       c.ContactEmail,
+	}
+}
+
+// APIClient renvoie le client football-data.org configuré, ou nil si
+// aucune clé n'est définie.
+func (c *Config) APIClient() *apiclient.Client {
+	if c.APIKey == "" {
+		return nil
+	}
+	client := apiclient.New(c.APIKey)
+	client.BaseURL = c.APIURL
+	client.Competition = c.Competition
+	return client
+}
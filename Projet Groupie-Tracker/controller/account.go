@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"groupie_tracker/auth"
+	"groupie_tracker/ratelimit"
+)
+
+// authStore persiste les comptes, sessions et favoris des utilisateurs
+// connectés. Il reste nil si la base n'a pas pu être ouverte : l'application
+// fonctionne alors uniquement avec le cookie "favorites".
+var authStore *auth.Store
+
+// Limites des tentatives de connexion et d'inscription, contre le
+// bourrage d'identifiants : par adresse IP, et par couple e-mail / IP pour
+// la connexion, plus strict sur un même compte. Une limite par e-mail
+// seul permettrait à n'importe qui de bloquer la connexion d'un autre
+// utilisateur en épuisant ses tentatives.
+var (
+	loginIPLimiter      = ratelimit.New(20, 15*time.Minute)
+	loginAccountLimiter = ratelimit.New(10, 15*time.Minute)
+	registerLimiter     = ratelimit.New(10, time.Hour)
+)
+
+// tooManyRequests ré-affiche le formulaire `name` avec le statut 429 et
+// l'en-tête Retry-After.
+func tooManyRequests(w http.ResponseWriter, r *http.Request, name string, data PageData, retry time.Duration) {
+	setRetryAfter(w, retry)
+	data.Message = tr(r, "error.429")
+	renderPage(w, r, http.StatusTooManyRequests, name, data)
+}
+
+// SetAuthStore active les comptes utilisateurs avec le store `s`.
+func SetAuthStore(s *auth.Store) {
+	authStore = s
+}
+
+// currentUser renvoie l'utilisateur connecté, ou nil en anonyme ou si
+// les comptes sont désactivés.
+func currentUser(r *http.Request) *auth.User {
+	if authStore == nil {
+		return nil
+	}
+	return auth.UserFromContext(r.Context())
+}
+
+// startSession ouvre une session pour `user`, écrit le cookie de session
+// et migre dans le compte les favoris présents dans le cookie "favorites"
+// (le cookie est ensuite vidé pour ne pas les ré-importer).
+func startSession(w http.ResponseWriter, r *http.Request, user *auth.User) error {
+	token, err := authStore.CreateSession(user.ID)
+	if err != nil {
+		return err
+	}
+	auth.SetSessionCookie(w, r, token)
+
+	if ids := GetFavoritesFromCookie(r); len(ids) > 0 {
+		if err := authStore.AddFavorites(user.ID, ids...); err != nil {
+			log.Printf("failed to migrate cookie favorites for user %d: %v", user.ID, err)
+			return nil
+		}
+		clearFavoritesCookie(w)
+	}
+	return nil
+}
+
+// Register gère `/register`.
+// GET affiche le formulaire d'inscription ; POST crée le compte à partir
+// des champs `email` et `password`, ouvre une session (ce qui migre les
+// favoris du cookie) puis redirige vers l'accueil. En cas d'erreur de
+// validation, le formulaire est ré-affiché avec un message.
+func Register(w http.ResponseWriter, r *http.Request) {
+	if authStore == nil {
+		NotFound(w, r)
+		return
+	}
+	data := PageData{
+		Title:   tr(r, "register.title"),
+		Message: tr(r, "register.message"),
+		Meta:    Meta{NoIndex: true},
+	}
+	if r.Method != http.MethodPost {
+		renderTemplate(w, r, "register.html", data)
+		return
+	}
+	if ok, retry := registerLimiter.Allow(clientIP(r)); !ok {
+		tooManyRequests(w, r, "register.html", data, retry)
+		return
+	}
+
+	user, err := authStore.CreateUser(r.FormValue("email"), r.FormValue("password"))
+	switch {
+	case errors.Is(err, auth.ErrEmailTaken):
+		data.Message = tr(r, "register.email_taken")
+	case errors.Is(err, auth.ErrInvalidEmail):
+		data.Message = tr(r, "register.invalid_email")
+	case errors.Is(err, auth.ErrWeakPassword):
+		data.Message = tr(r, "register.weak_password")
+	case err != nil:
+		log.Printf("register failed: %v", err)
+		data.Message = tr(r, "register.failed")
+	}
+	if err != nil {
+		renderPage(w, r, http.StatusBadRequest, "register.html", data)
+		return
+	}
+
+	if err := startSession(w, r, user); err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("session creation failed: %w", err))
+		return
+	}
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// Login gère `/login`.
+// GET affiche le formulaire de connexion ; POST vérifie `email` et
+// `password`, ouvre une session (ce qui migre les favoris du cookie)
+// puis redirige vers `next` (page locale demandée avant la connexion,
+// voir `safeNext`) ou vers l'accueil.
+func Login(w http.ResponseWriter, r *http.Request) {
+	if authStore == nil {
+		NotFound(w, r)
+		return
+	}
+	data := PageData{
+		Title:   tr(r, "login.title"),
+		Message: tr(r, "login.message"),
+		Next:    safeNext(r.FormValue("next")),
+		Meta:    Meta{NoIndex: true},
+	}
+	if r.Method != http.MethodPost {
+		renderTemplate(w, r, "login.html", data)
+		return
+	}
+	email := strings.ToLower(strings.TrimSpace(r.FormValue("email")))
+	if ok, retry := loginIPLimiter.Allow(clientIP(r)); !ok {
+		tooManyRequests(w, r, "login.html", data, retry)
+		return
+	}
+	if ok, retry := loginAccountLimiter.Allow(email + "\x00" + clientIP(r)); !ok {
+		tooManyRequests(w, r, "login.html", data, retry)
+		return
+	}
+
+	user, err := authStore.Authenticate(email, r.FormValue("password"))
+	if err != nil {
+		if !errors.Is(err, auth.ErrInvalidCredentials) {
+			log.Printf("login failed: %v", err)
+		}
+		data.Message = tr(r, "login.invalid")
+		renderPage(w, r, http.StatusUnauthorized, "login.html", data)
+		return
+	}
+
+	if err := startSession(w, r, user); err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("session creation failed: %w", err))
+		return
+	}
+	next := data.Next
+	if next == "" {
+		next = "/"
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// safeNext renvoie `next` s'il désigne une page de ce site (chemin
+// absolu local), et "" sinon, pour éviter les redirections ouvertes.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return ""
+	}
+	return next
+}
+
+// Logout gère `/logout` (POST) : ferme la session côté serveur, supprime
+// le cookie de session et redirige vers l'accueil.
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	if authStore != nil {
+		if cookie, err := r.Cookie(auth.SessionCookie); err == nil {
+			if err := authStore.DeleteSession(cookie.Value); err != nil {
+				log.Printf("failed to delete session: %v", err)
+			}
+		}
+	}
+	auth.ClearSessionCookie(w, r)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
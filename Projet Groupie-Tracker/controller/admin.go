@@ -0,0 +1,415 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+// maxCrestSize limite la taille d'un blason envoyé depuis l'administration.
+const maxCrestSize = 1 << 20
+
+// crestTypes associe les types de blasons acceptés à leur extension. Le
+// SVG est refusé : servi tel quel sous `/static/`, un script qu'il
+// contiendrait s'exécuterait avec l'origine du site.
+var crestTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+}
+
+// AdminConfig décrit qui peut accéder à `/admin` et où sont stockés les
+// blasons envoyés.
+type AdminConfig struct {
+	// Emails liste les comptes (connectés via `/login`) administrateurs.
+	Emails []string
+	// User et Password activent en plus l'authentification HTTP Basic.
+	User     string
+	Password string
+	// CrestDir est le répertoire des blasons, servi sous `/static/crests/`.
+	CrestDir string
+}
+
+// admin est la configuration de l'administration ; sans e-mail ni mot de
+// passe, `/admin` reste inaccessible.
+var admin AdminConfig
+
+// SetAdmin configure l'accès à l'administration.
+func SetAdmin(cfg AdminConfig) {
+	admin = cfg
+}
+
+// isAdmin indique si la requête provient d'un administrateur : compte
+// connecté dont l'e-mail est listé, ou identifiants HTTP Basic valides.
+func isAdmin(r *http.Request) bool {
+	if user := currentUser(r); user != nil {
+		for _, email := range admin.Emails {
+			if strings.EqualFold(strings.TrimSpace(email), user.Email) {
+				return true
+			}
+		}
+	}
+	if admin.Password != "" {
+		if u, p, ok := r.BasicAuth(); ok {
+			userOK := subtle.ConstantTimeCompare([]byte(u), []byte(admin.User)) == 1
+			passOK := subtle.ConstantTimeCompare([]byte(p), []byte(admin.Password)) == 1
+			return userOK && passOK
+		}
+	}
+	return false
+}
+
+// AdminOnly protège un handler d'administration. Un visiteur non autorisé
+// est invité à s'authentifier (HTTP Basic si un mot de passe est
+// configuré, sinon redirection vers `/login`) ; un utilisateur connecté
+// sans droits reçoit 403. Sans configuration, la page n'existe pas (404).
+func AdminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if isAdmin(r) {
+			w.Header().Set("Cache-Control", "no-store")
+			next(w, r)
+			return
+		}
+		switch {
+		case len(admin.Emails) == 0 && admin.Password == "":
+			NotFound(w, r)
+		case admin.Password != "":
+			w.Header().Set("WWW-Authenticate", `Basic realm="Groupie Tracker admin", charset="UTF-8"`)
+			RenderError(w, r, http.StatusUnauthorized, "", nil)
+		case currentUser(r) == nil && authStore != nil:
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+		default:
+			RenderError(w, r, http.StatusForbidden, "", nil)
+		}
+	}
+}
+
+// MetricsOnly protège `/metrics`, qui ne doit pas être public : la requête
+// doit porter l'en-tête `Authorization: Bearer <token>` (si `token` est
+// configuré) ou venir d'un administrateur, comme pour `AdminOnly`. Un
+// jeton invalide renvoie 401.
+func MetricsOnly(token string, next http.Handler) http.HandlerFunc {
+	protected := AdminOnly(next.ServeHTTP)
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, hasBearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		switch {
+		case token != "" && hasBearer && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1:
+			next.ServeHTTP(w, r)
+		case token != "" && hasBearer:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="Groupie Tracker metrics"`)
+			RenderError(w, r, http.StatusUnauthorized, "", nil)
+		default:
+			protected(w, r)
+		}
+	}
+}
+
+// AdminClubs gère `/admin` et liste tous les clubs avec leurs actions
+// (modifier, supprimer) et un lien de création.
+func AdminClubs(w http.ResponseWriter, r *http.Request) {
+	clubs, err := clubRepo.All()
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to load clubs: %w", err))
+		return
+	}
+	data := PageData{
+		Title:   tr(r, "admin.title"),
+		Message: r.URL.Query().Get("msg"),
+		Clubs:   clubs,
+		User:    currentUser(r),
+		Meta:    Meta{NoIndex: true},
+	}
+	renderTemplate(w, r, "admin.html", data)
+}
+
+// AdminNewClub gère `/admin/clubs/new`.
+// GET affiche le formulaire vide ; POST valide les champs, enregistre le
+// blason éventuel puis crée le club et redirige vers `/admin`.
+func AdminNewClub(w http.ResponseWriter, r *http.Request) {
+	data := PageData{Title: tr(r, "admin.new"), IsNew: true, User: currentUser(r), Meta: Meta{NoIndex: true}}
+	if r.Method != http.MethodPost {
+		renderTemplate(w, r, "admin_club.html", data)
+		return
+	}
+
+	club, errs := clubFromForm(w, r)
+	data.Club, data.Errors = club, errs
+	if len(errs) == 0 {
+		var err error
+		var crestFile string
+		club, crestFile, err = saveCrest(r, club)
+		if err == nil {
+			club, err = clubRepo.Create(club)
+			if err != nil {
+				removeCrest(crestFile)
+			}
+		}
+		switch {
+		case errors.Is(err, models.ErrClubExists):
+			errs["id"] = tr(r, "admin.error.id_taken")
+		case err != nil:
+			adminFailed(w, r, data, err)
+			return
+		default:
+			redirectAdmin(w, r, tr(r, "admin.created", club.Name))
+			return
+		}
+	}
+	renderPage(w, r, http.StatusUnprocessableEntity, "admin_club.html", data)
+}
+
+// AdminEditClub gère `/admin/clubs/{id}`.
+// GET affiche le formulaire pré-rempli ; POST valide les champs et
+// remplace le club (l'ID ne change pas). Sans nouveau fichier, le blason
+// actuel est conservé ; sinon l'ancien fichier envoyé est supprimé (voir
+// `uploadedCrest`).
+func AdminEditClub(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		NotFound(w, r)
+		return
+	}
+	current, err := clubRepo.ByID(id)
+	if errors.Is(err, models.ErrClubNotFound) {
+		NotFound(w, r)
+		return
+	}
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to load club %d: %w", id, err))
+		return
+	}
+
+	data := PageData{Title: tr(r, "admin.edit", current.Name), Club: current, User: currentUser(r), Meta: Meta{NoIndex: true}}
+	if r.Method != http.MethodPost {
+		renderTemplate(w, r, "admin_club.html", data)
+		return
+	}
+
+	club, errs := clubFromForm(w, r)
+	club.ID = id
+	if club.CrestURL == "" {
+		club.CrestURL = current.CrestURL
+	}
+	data.Club, data.Errors = club, errs
+	if len(errs) == 0 {
+		var crestFile string
+		club, crestFile, err = saveCrest(r, club)
+		if err == nil {
+			if err = clubRepo.Update(club); err != nil {
+				removeCrest(crestFile)
+			}
+		}
+		if err != nil {
+			adminFailed(w, r, data, err)
+			return
+		}
+		if club.CrestURL != current.CrestURL {
+			removeCrest(uploadedCrest(current.CrestURL))
+		}
+		redirectAdmin(w, r, tr(r, "admin.updated", club.Name))
+		return
+	}
+	renderPage(w, r, http.StatusUnprocessableEntity, "admin_club.html", data)
+}
+
+// AdminDeleteClub gère `/admin/clubs/{id}/delete` (POST uniquement) et
+// supprime le club, ainsi que son blason s'il a été envoyé depuis
+// l'administration. Les favoris qui le référencent sont ignorés à
+// l'affichage.
+func AdminDeleteClub(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		NotFound(w, r)
+		return
+	}
+	club, err := clubRepo.ByID(id)
+	if err == nil {
+		err = clubRepo.Delete(id)
+	}
+	if errors.Is(err, models.ErrClubNotFound) {
+		NotFound(w, r)
+		return
+	}
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to delete club %d: %w", id, err))
+		return
+	}
+	removeCrest(uploadedCrest(club.CrestURL))
+	redirectAdmin(w, r, tr(r, "admin.deleted", id))
+}
+
+// clubFromForm lit les champs du formulaire d'administration et renvoie
+// le club et ses erreurs de validation par champ.
+func clubFromForm(w http.ResponseWriter, r *http.Request) (models.Club, map[string]string) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCrestSize+64<<10)
+	errs := map[string]string{}
+	if err := r.ParseMultipartForm(maxCrestSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		errs["crest"] = tr(r, "admin.error.form_size")
+	}
+
+	club := models.Club{
+		Name:        strings.TrimSpace(r.FormValue("name")),
+		ShortName:   strings.TrimSpace(r.FormValue("shortName")),
+		TLA:         strings.ToUpper(strings.TrimSpace(r.FormValue("tla"))),
+		Website:     strings.TrimSpace(r.FormValue("website")),
+		Venue:       strings.TrimSpace(r.FormValue("venue")),
+		CrestURL:    strings.TrimSpace(r.FormValue("crestUrl")),
+		Country:     strings.TrimSpace(r.FormValue("country")),
+		City:        strings.TrimSpace(r.FormValue("city")),
+		Address:     strings.TrimSpace(r.FormValue("address")),
+		Colors:      strings.TrimSpace(r.FormValue("colors")),
+		Competition: strings.TrimSpace(r.FormValue("competition")),
+	}
+	if v := strings.TrimSpace(r.FormValue("id")); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			errs["id"] = tr(r, "admin.error.id")
+		}
+		club.ID = id
+	}
+	if v := strings.TrimSpace(r.FormValue("founded")); v != "" {
+		founded, err := strconv.Atoi(v)
+		if err != nil {
+			errs["founded"] = tr(r, "admin.error.founded")
+		}
+		club.Founded = founded
+	}
+	for field, msg := range trErrors(r, club.Validate()) {
+		if _, ok := errs[field]; !ok {
+			errs[field] = msg
+		}
+	}
+	if _, ok := errs["crest"]; !ok {
+		if msg := checkCrest(r); msg != "" {
+			errs["crest"] = msg
+		}
+	}
+	return club, errs
+}
+
+// checkCrest vérifie le fichier `crest` envoyé, s'il y en a un : taille
+// et type (PNG, JPEG ou WebP). Renvoie un message d'erreur traduit ou "".
+func checkCrest(r *http.Request) string {
+	if r.MultipartForm == nil || len(r.MultipartForm.File["crest"]) == 0 {
+		return ""
+	}
+	fh := r.MultipartForm.File["crest"][0]
+	if fh.Size > maxCrestSize {
+		return tr(r, "admin.error.crest_size")
+	}
+	if _, err := crestType(r); err != nil {
+		return tr(r, "admin.error.crest_type")
+	}
+	return ""
+}
+
+// crestType détecte le type du blason envoyé d'après son contenu.
+func crestType(r *http.Request) (string, error) {
+	f, _, err := r.FormFile("crest")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	ct := http.DetectContentType(head)
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+	if _, ok := crestTypes[ct]; ok {
+		return ct, nil
+	}
+	return "", errors.New("unsupported crest type")
+}
+
+// saveCrest enregistre le blason envoyé (s'il y en a un) dans
+// `admin.CrestDir` sous le nom `club-<id>-<horodatage>.<ext>`, renseigne
+// `CrestURL` et renvoie le chemin du fichier écrit ("" sans envoi), à
+// supprimer avec `removeCrest` si le club ne peut pas être enregistré.
+// Pour un nouveau club sans ID, l'horodatage seul garantit un nom unique.
+func saveCrest(r *http.Request, club models.Club) (models.Club, string, error) {
+	if r.MultipartForm == nil || len(r.MultipartForm.File["crest"]) == 0 {
+		return club, "", nil
+	}
+	fh := r.MultipartForm.File["crest"][0]
+	ct, err := crestType(r)
+	if err != nil {
+		return club, "", err
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return club, "", err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(admin.CrestDir, 0o755); err != nil {
+		return club, "", err
+	}
+	name := fmt.Sprintf("club-%d-%d%s", club.ID, time.Now().UnixNano(), crestTypes[ct])
+	path := filepath.Join(admin.CrestDir, name)
+	dst, err := os.Create(path)
+	if err != nil {
+		return club, "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		removeCrest(path)
+		return club, "", err
+	}
+	if err := dst.Close(); err != nil {
+		removeCrest(path)
+		return club, "", err
+	}
+	club.CrestURL = "/static/crests/" + name
+	return club, path, nil
+}
+
+// removeCrest supprime le blason `path` écrit par saveCrest ("" : rien).
+func removeCrest(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("admin: cannot remove crest %s: %v", path, err)
+	}
+}
+
+// uploadedCrest renvoie le fichier du blason `crestURL` s'il a été écrit
+// par saveCrest (`/static/crests/club-...`), "" sinon : les blasons
+// fournis avec le site et les URL distantes ne sont jamais supprimés.
+func uploadedCrest(crestURL string) string {
+	name, ok := strings.CutPrefix(crestURL, "/static/crests/")
+	if !ok || !strings.HasPrefix(name, "club-") || strings.ContainsAny(name, `/\`) || admin.CrestDir == "" {
+		return ""
+	}
+	return filepath.Join(admin.CrestDir, name)
+}
+
+// adminFailed journalise une erreur d'enregistrement et ré-affiche le
+// formulaire avec un message générique.
+func adminFailed(w http.ResponseWriter, r *http.Request, data PageData, err error) {
+	log.Printf("admin: cannot save club: %v", err)
+	data.Message = tr(r, "admin.failed")
+	renderPage(w, r, http.StatusInternalServerError, "admin_club.html", data)
+}
+
+// redirectAdmin revient à la liste des clubs avec un message de confirmation.
+func redirectAdmin(w http.ResponseWriter, r *http.Request, msg string) {
+	http.Redirect(w, r, "/admin?msg="+url.QueryEscape(msg), http.StatusSeeOther)
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetricsOnly(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name       string
+		token      string
+		admin      AdminConfig
+		auth       func(r *http.Request)
+		wantStatus int
+	}{
+		{"no token nor admin", "", AdminConfig{}, nil, http.StatusNotFound},
+		{"valid token", "s3cret", AdminConfig{}, func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer s3cret")
+		}, http.StatusOK},
+		{"invalid token", "s3cret", AdminConfig{}, func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer nope")
+		}, http.StatusUnauthorized},
+		{"token not configured", "", AdminConfig{}, func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer ")
+		}, http.StatusNotFound},
+		{"missing token", "s3cret", AdminConfig{User: "admin", Password: "pw"}, nil, http.StatusUnauthorized},
+		{"admin", "s3cret", AdminConfig{User: "admin", Password: "pw"}, func(r *http.Request) {
+			r.SetBasicAuth("admin", "pw")
+		}, http.StatusOK},
+	}
+	t.Cleanup(func() { SetAdmin(AdminConfig{}) })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetAdmin(tt.admin)
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.auth != nil {
+				tt.auth(r)
+			}
+			w := httptest.NewRecorder()
+			MetricsOnly(tt.token, ok)(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUploadedCrest(t *testing.T) {
+	t.Cleanup(func() { SetAdmin(AdminConfig{}) })
+	SetAdmin(AdminConfig{CrestDir: "crests"})
+	tests := []struct{ url, want string }{
+		{"/static/crests/club-7-123.png", "crests/club-7-123.png"},
+		{"/static/crests/mci.png", ""},
+		{"/static/crests/club-7/../../x.png", ""},
+		{"https://crests.football-data.org/65.png", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := uploadedCrest(tt.url); got != tt.want {
+			t.Errorf("uploadedCrest(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"groupie_tracker/models"
+)
+
+// maxCompare est le nombre maximal de clubs comparés côte à côte.
+const maxCompare = 4
+
+// ComparedClub regroupe les informations d'un club affichées dans le
+// tableau de comparaison.
+type ComparedClub struct {
+	Club      models.Club `json:"club"`
+	SquadSize int         `json:"squadSize"`
+	// Position au classement de `Competition` (0 si le club n'est pas
+	// classé, faute de matchs terminés).
+	Position    int    `json:"position,omitempty"`
+	Points      int    `json:"points,omitempty"`
+	Competition string `json:"competition,omitempty"`
+}
+
+type CompareResponse struct {
+	Clubs         []ComparedClub `json:"clubs"`
+	FromFavorites bool           `json:"fromFavorites"`
+}
+
+// ComparedSquad associe un club au résumé de son effectif.
+type ComparedSquad struct {
+	Club  models.Club       `json:"club"`
+	Stats models.SquadStats `json:"stats"`
+}
+
+type CompareSquadsResponse struct {
+	Squads        []ComparedSquad `json:"squads"`
+	FromFavorites bool            `json:"fromFavorites"`
+}
+
+// errUnknownClub signale un ID de `ids` ne correspondant à aucun club.
+var errUnknownClub = errors.New("unknown club")
+
+// compareIDs lit la liste `ids` (ex: "1,2,3") de la requête. Sans `ids`,
+// les favoris du visiteur sont utilisés (les `maxCompare` premiers) et
+// `fromFavorites` vaut true. Un ID invalide ou plus de `maxCompare` IDs
+// renvoient une erreur ; les doublons sont ignorés.
+func compareIDs(r *http.Request) (ids []int, fromFavorites bool, err error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("ids"))
+	values := strings.Split(raw, ",")
+	if raw == "" {
+		values, fromFavorites = getFavorites(r), true
+	}
+	seen := map[int]bool{}
+	for _, v := range values {
+		id, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || id <= 0 {
+			if fromFavorites {
+				continue
+			}
+			return nil, false, fmt.Errorf("invalid club id %q", v)
+		}
+		if seen[id] {
+			continue
+		}
+		if len(ids) == maxCompare {
+			if fromFavorites {
+				break
+			}
+			return nil, false, fmt.Errorf("at most %d clubs can be compared", maxCompare)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, fromFavorites, nil
+}
+
+// compareClubs charge les clubs `ids` avec la taille de leur effectif et
+// leur position au classement. Un ID inconnu renvoie `errUnknownClub`,
+// sauf pour les favoris (`skipUnknown`) où il est simplement ignoré.
+func compareClubs(ids []int, skipUnknown bool) ([]ComparedClub, error) {
+	positions := map[int]ComparedClub{}
+	for _, s := range loadStandings("") {
+		for _, row := range s.Table {
+			if _, ok := positions[row.Club.ID]; !ok {
+				positions[row.Club.ID] = ComparedClub{Position: row.Position, Points: row.Points, Competition: s.Competition}
+			}
+		}
+	}
+
+	compared := []ComparedClub{}
+	for _, id := range ids {
+		club, err := clubRepo.ByID(id)
+		if errors.Is(err, models.ErrClubNotFound) {
+			if skipUnknown {
+				continue
+			}
+			return nil, fmt.Errorf("%w: %d", errUnknownClub, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		c := positions[id]
+		c.Club = club
+		c.SquadSize = len(loadSquad(id))
+		compared = append(compared, c)
+	}
+	return compared, nil
+}
+
+// Compare gère `/compare?ids=1,2,3` et affiche jusqu'à 4 clubs côte à
+// côte (année de fondation, stade, pays, taille de l'effectif, position
+// au classement) avec le template `compare.html`. Sans `ids`, les favoris
+// du visiteur sont comparés. Une liste invalide renvoie une erreur 400,
+// un club inconnu la page 404.
+func Compare(w http.ResponseWriter, r *http.Request) {
+	ids, fromFavorites, err := compareIDs(r)
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, tr(r, "compare.invalid", err.Error()), nil)
+		return
+	}
+	compared, err := compareClubs(ids, fromFavorites)
+	if errors.Is(err, errUnknownClub) {
+		NotFound(w, r)
+		return
+	}
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to compare clubs: %w", err))
+		return
+	}
+
+	data := PageData{
+		Title:    tr(r, "compare.title"),
+		Message:  tr(r, "compare.message"),
+		Compared: compared,
+		User:     currentUser(r),
+		Meta:     Meta{NoIndex: true},
+	}
+	if fromFavorites {
+		data.Message = tr(r, "compare.favorites")
+	}
+	renderTemplate(w, r, "compare.html", data)
+}
+
+// CompareAPI fournit l'endpoint `/api/compare` en JSON, avec les mêmes
+// paramètres que `Compare`. Les erreurs sont renvoyées en JSON (400 pour
+// une liste invalide, 404 pour un club inconnu).
+func CompareAPI(w http.ResponseWriter, r *http.Request) {
+	ids, fromFavorites, err := compareIDs(r)
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	compared, err := compareClubs(ids, fromFavorites)
+	if errors.Is(err, errUnknownClub) {
+		RenderError(w, r, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "cannot load clubs", fmt.Errorf("failed to compare clubs: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CompareResponse{Clubs: compared, FromFavorites: fromFavorites})
+}
+
+// compareSquads charge les clubs `ids` et calcule le résumé de leur
+// effectif (voir `models.ComputeSquadStats`). Les IDs inconnus sont
+// traités comme dans `compareClubs`.
+func compareSquads(ids []int, skipUnknown bool) ([]ComparedSquad, error) {
+	squads := []ComparedSquad{}
+	for _, id := range ids {
+		club, err := clubRepo.ByID(id)
+		if errors.Is(err, models.ErrClubNotFound) {
+			if skipUnknown {
+				continue
+			}
+			return nil, fmt.Errorf("%w: %d", errUnknownClub, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		squads = append(squads, ComparedSquad{Club: club, Stats: models.ComputeSquadStats(loadSquad(id))})
+	}
+	return squads, nil
+}
+
+// CompareSquads gère `/compare/squads?ids=1,2` et compare les effectifs
+// des clubs côte à côte (âge moyen, nationalités, couverture des postes)
+// avec le template `compare_squads.html`. Les paramètres et les erreurs
+// sont ceux de `Compare`.
+func CompareSquads(w http.ResponseWriter, r *http.Request) {
+	ids, fromFavorites, err := compareIDs(r)
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, tr(r, "compare.invalid", err.Error()), nil)
+		return
+	}
+	squads, err := compareSquads(ids, fromFavorites)
+	if errors.Is(err, errUnknownClub) {
+		NotFound(w, r)
+		return
+	}
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to compare squads: %w", err))
+		return
+	}
+	renderTemplate(w, r, "compare_squads.html", PageData{
+		Title:   tr(r, "compare_squads.title"),
+		Message: tr(r, "compare_squads.message"),
+		Squads:  squads,
+		User:    currentUser(r),
+		Meta:    Meta{NoIndex: true},
+	})
+}
+
+// CompareSquadsAPI fournit l'endpoint `/api/compare/squads` en JSON, avec
+// les mêmes paramètres et erreurs que `CompareAPI`.
+func CompareSquadsAPI(w http.ResponseWriter, r *http.Request) {
+	ids, fromFavorites, err := compareIDs(r)
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	squads, err := compareSquads(ids, fromFavorites)
+	if errors.Is(err, errUnknownClub) {
+		RenderError(w, r, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "cannot load squads", fmt.Errorf("failed to compare squads: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(CompareSquadsResponse{Squads: squads, FromFavorites: fromFavorites})
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"groupie_tracker/models"
+	"groupie_tracker/ratelimit"
+	"groupie_tracker/storage"
+)
+
+// maxContactBody limite la taille d'un formulaire de contact envoyé.
+const maxContactBody = 64 << 10
+
+// contactQueueSize borne le nombre de messages en attente d'envoi : au
+// delà (serveur SMTP lent ou injoignable), les nouveaux messages sont
+// seulement enregistrés.
+const contactQueueSize = 100
+
+// contactLimiter limite les envois du formulaire de contact par adresse IP.
+var contactLimiter = ratelimit.New(5, 10*time.Minute)
+
+// contactQueue transmet les messages enregistrés à l'unique goroutine
+// d'envoi (voir `notifyContact`), lancée au premier message.
+var (
+	contactQueue  = make(chan models.ContactMessage, contactQueueSize)
+	contactWorker sync.Once
+)
+
+// ContactNotifier transmet les messages de contact reçus (par exemple
+// par e-mail, voir `mailer.Mailer`).
+type ContactNotifier interface {
+	SendContact(msg models.ContactMessage) error
+}
+
+// contactStore enregistre les messages du formulaire de contact.
+// Il est remplacé au démarrage par `SetContactStore` (voir `router.New`).
+var contactStore storage.ContactStore = storage.NewJSONContactStore("data/contacts.json")
+
+// contactNotifier est nil tant qu'aucun envoi n'est configuré.
+var contactNotifier ContactNotifier
+
+// SetContactStore définit le store des messages de contact.
+func SetContactStore(s storage.ContactStore) {
+	contactStore = s
+}
+
+// SetContactNotifier définit l'envoi des messages de contact reçus ;
+// nil désactive l'envoi.
+func SetContactNotifier(n ContactNotifier) {
+	contactNotifier = n
+}
+
+// notifyContact place `msg` dans la file d'envoi, sans attendre : si la
+// file est pleine, le message n'est pas transmis (il reste consultable
+// dans `/admin/messages`).
+func notifyContact(msg models.ContactMessage) {
+	contactWorker.Do(func() {
+		go func() {
+			for msg := range contactQueue {
+				if err := contactNotifier.SendContact(msg); err != nil {
+					log.Printf("failed to send contact message %d: %v", msg.ID, err)
+				}
+			}
+		}()
+	})
+	select {
+	case contactQueue <- msg:
+	default:
+		log.Printf("contact notification queue full, message %d not sent", msg.ID)
+	}
+}
+
+// Contact gère la route `/contact`.
+// Pour une requête POST, elle lit les champs du formulaire (`name`,
+// `email`, `msg`), les valide (voir `models.ContactMessage.Validate`),
+// enregistre le message puis affiche un message de remerciement ; si un
+// envoi est configuré, le message est aussi transmis par e-mail en
+// arrière-plan (voir `notifyContact`). Un formulaire invalide est
+// ré-affiché avec ses erreurs (statut 422). Les envois sont limités par
+// adresse IP (`contactLimiter`, statut 429), et un formulaire dont le
+// champ piège `website` est rempli est ignoré. Pour GET, elle affiche le
+// formulaire de contact vide.
+func Contact(w http.ResponseWriter, r *http.Request) {
+	data := PageData{
+		Title:   tr(r, "contact.title"),
+		Message: tr(r, "contact.message"),
+		User:    currentUser(r),
+		Meta:    pageMeta(r, "meta.contact"),
+	}
+	if r.Method != http.MethodPost {
+		renderTemplate(w, r, "contact.html", data)
+		return
+	}
+
+	if ok, retry := contactLimiter.Allow(clientIP(r)); !ok {
+		setRetryAfter(w, retry)
+		data.Message = tr(r, "error.429")
+		renderPage(w, r, http.StatusTooManyRequests, "contact.html", data)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxContactBody)
+	msg := models.NewContactMessage(r.FormValue("name"), r.FormValue("email"), r.FormValue("msg"))
+	if r.FormValue("website") != "" {
+		// Champ piège, invisible pour les visiteurs : rempli par un robot.
+		// Il reçoit la même réponse qu'un envoi réussi.
+		data.Message = tr(r, "contact.thanks", msg.Name, msg.Message)
+		renderTemplate(w, r, "contact.html", data)
+		return
+	}
+	if errs := msg.Validate(); len(errs) > 0 {
+		data.Message, data.Contact, data.Errors = tr(r, "contact.invalid"), msg, trErrors(r, errs)
+		renderPage(w, r, http.StatusUnprocessableEntity, "contact.html", data)
+		return
+	}
+
+	saved, err := contactStore.Save(msg)
+	if err != nil {
+		log.Printf("failed to save contact message: %v", err)
+		data.Message, data.Contact = tr(r, "contact.failed"), msg
+		renderPage(w, r, http.StatusInternalServerError, "contact.html", data)
+		return
+	}
+	if contactNotifier != nil {
+		notifyContact(saved)
+	}
+
+	data.Message = tr(r, "contact.thanks", saved.Name, saved.Message)
+	renderTemplate(w, r, "contact.html", data)
+}
+
+// AdminMessages gère `/admin/messages` et liste les messages reçus avec
+// le formulaire de contact, du plus récent au plus ancien.
+func AdminMessages(w http.ResponseWriter, r *http.Request) {
+	msgs, err := contactStore.List()
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to load contact messages: %w", err))
+		return
+	}
+	data := PageData{
+		Title:    tr(r, "admin.messages"),
+		Messages: msgs,
+		User:     currentUser(r),
+		Meta:     Meta{NoIndex: true},
+	}
+	renderTemplate(w, r, "admin_messages.html", data)
+}
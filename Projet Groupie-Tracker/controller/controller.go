@@ -2,16 +2,19 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
 	"strconv"
 	"strings"
 
+	"groupie_tracker/auth"
+	"groupie_tracker/middleware"
 	"groupie_tracker/models"
+	"groupie_tracker/storage"
 )
 
 type PageData struct {
@@ -23,14 +26,120 @@ type PageData struct {
 	SearchQuery string
 	MinYear     string
 	MaxYear     string
+	Country     string
+	Venue       string
+	Countries   []string // pays distincts, pour la liste déroulante du filtre
+	Sort        string
+	Order       string
+	Results     []models.SearchResult
+	Club        models.Club
+	IsFavorite  bool
+	Players     []models.Player
+	Matches     []MatchView
+	Filters     url.Values
+	Standings   []models.CompetitionStandings
+	Compared    []ComparedClub
+	Squads      []ComparedSquad
+	Explorer    *Explorer // collection Postman (`/api-explorer`)
+	Meta        Meta      // description, URL canonique et aperçu (voir `metaTags`)
+	Status      int       // statut HTTP des pages d'erreur (voir `RenderError`)
+	User        *auth.User
+	ShareToken  string
+	ShareURL    string
+	Errors      map[string]string
+	Contact     models.ContactMessage   // saisie du formulaire de contact
+	Messages    []models.ContactMessage // messages reçus (administration)
+	IsNew       bool
+	Next        string
+	// Pagination de la liste des clubs (page d'accueil).
+	CurrentPage int
+	TotalPages  int
+	PageSize    int
+	TotalClubs  int
+	PrevPage    int   // 0 s'il n'y a pas de page précédente
+	NextPage    int   // 0 s'il n'y a pas de page suivante
+	Pages       []int // pages à proposer ; 0 marque un saut ("…")
 }
 
 type FilterResponse struct {
-	Clubs      []models.Club `json:"clubs"`
-	Total      int           `json:"total"`
-	Page       int           `json:"page"`
-	PageSize   int           `json:"pageSize"`
-	TotalPages int           `json:"totalPages"`
+	Clubs      interface{} `json:"clubs"`
+	Total      int         `json:"total"`
+	Page       int         `json:"page"`
+	PageSize   int         `json:"pageSize"`
+	TotalPages int         `json:"totalPages"`
+	Sort       string      `json:"sort,omitempty"`
+	Order      string      `json:"order"`
+	Countries  []string    `json:"countries"`
+}
+
+type SearchResponse struct {
+	Query   string                `json:"query"`
+	Results []models.SearchResult `json:"results"`
+	Total   int                   `json:"total"`
+}
+
+type SuggestResponse struct {
+	Query       string              `json:"query"`
+	Suggestions []models.Suggestion `json:"suggestions"`
+}
+
+// selectFields réduit chaque club aux seuls champs JSON demandés
+// (ex: "id,name,crestUrl"). Les noms inconnus sont ignorés ; si aucun
+// champ demandé n'est valide, les clubs sont renvoyés tels quels.
+// Un champ vide (omis par `omitempty`) reste absent de la sortie.
+func selectFields(clubs []models.Club, fields string) interface{} {
+	known := make(map[string]bool)
+	for _, f := range strings.Split(fields, ",") {
+		f = strings.TrimSpace(f)
+		if models.IsClubField(f) {
+			known[f] = true
+		}
+	}
+	if len(known) == 0 {
+		return clubs
+	}
+
+	trimmed := make([]map[string]json.RawMessage, 0, len(clubs))
+	for _, club := range clubs {
+		b, err := json.Marshal(club)
+		if err != nil {
+			continue
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(b, &all); err != nil {
+			continue
+		}
+		out := make(map[string]json.RawMessage, len(known))
+		for k, v := range all {
+			if known[k] {
+				out[k] = v
+			}
+		}
+		trimmed = append(trimmed, out)
+	}
+	return trimmed
+}
+
+// clubRepo fournit la liste des clubs à tous les handlers (fichier JSON
+// en cache mémoire par défaut, ou base SQLite).
+// Il est remplacé au démarrage par `SetClubRepository` (voir `router.New`).
+var clubRepo storage.ClubRepository = storage.NewJSONRepository(
+	models.NewClubStore("data/clubs.json", models.DefaultClubTTL))
+
+// SetClubRepository définit le dépôt de clubs utilisé par les handlers.
+func SetClubRepository(repo storage.ClubRepository) {
+	clubRepo = repo
+}
+
+// loadClubs renvoie la liste des clubs depuis `clubRepo`.
+// En cas d'échec, l'erreur est loggée et une slice vide est renvoyée.
+func loadClubs() []models.Club {
+	clubs, err := clubRepo.All()
+	if err != nil {
+		log.Printf("failed to load clubs: %v", err)
+		return []models.Club{}
+	}
+	return clubs
 }
 
 // toJSON convertit une valeur Go en JSON sûr pour les templates.
@@ -44,184 +153,199 @@ func toJSON(v interface{}) template.JS {
 	return template.JS(b)
 }
 
-// renderTemplate localise et exécute un fichier de template HTML.
-// Elle cherche le template dans plusieurs chemins relatifs, prépare
-// la fonction `toJSON` pour les templates et écrit la sortie dans `w`.
-// En cas d'erreur de parsing ou d'exécution, elle logge et renvoie
-// une erreur HTTP 500 au client.
-func renderTemplate(w http.ResponseWriter, filename string, data interface{}) {
-
-	candidates := []string{
-		"template/" + filename,
-		"./template/" + filename,
-		"../template/" + filename,
-		"../../template/" + filename,
-	}
-	var path string
-	for _, p := range candidates {
-		if _, err := os.Stat(p); err == nil {
-			path = p
-			break
-		}
-	}
-	if path == "" {
-		// none found
-		msg := "template file missing; tried: " + candidates[0]
-		for _, c := range candidates[1:] {
-			msg += ", " + c
-		}
-		log.Print(msg)
-		http.Error(w, msg, http.StatusInternalServerError)
-		return
-	}
-	funcMap := template.FuncMap{
-		"toJSON": toJSON,
-	}
-	tmpl, err := template.New("").Funcs(funcMap).ParseFiles(path)
-	if err != nil {
-		log.Printf("template parse error (%s): %v", path, err)
-		http.Error(w, "template parse error: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-	if err := tmpl.ExecuteTemplate(w, filepath.Base(path), data); err != nil {
-		log.Printf("template execute error: %v", err)
-		http.Error(w, "template execute error: "+err.Error(), http.StatusInternalServerError)
-	}
+// csrfField renvoie le champ caché portant le jeton CSRF de la requête.
+func csrfField(r *http.Request) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + middleware.CSRFField +
+		`" value="` + template.HTMLEscapeString(middleware.CSRFToken(r)) + `">`)
 }
 
 // Home gère la route racine `/`.
-// Elle charge la liste des clubs depuis `data/clubs.json`, construit
+// Elle charge la liste des clubs via `loadClubs`, construit
 // les données de page (`PageData`) et rend le template `index.html`.
 // Si le chargement des clubs échoue, la liste est remplacée par une
 // slice vide et l'erreur est loggée.
 func Home(w http.ResponseWriter, r *http.Request) {
-	clubs, err := models.LoadClubsFromFile("data/clubs.json")
-	if err != nil {
-		log.Printf("failed to load clubs: %v", err)
-
-		clubs = []models.Club{}
-	}
+	clubs := loadClubs()
 	data := PageData{
-		Title:   "Accueil",
-		Message: "Bienvenue sur la page d'accueil",
+		Title:   tr(r, "home.title"),
+		Message: tr(r, "home.message"),
 		Clubs:   clubs,
+		Meta:    pageMeta(r, "meta.home"),
 	}
-	renderTemplate(w, "index.html", data)
+	renderTemplate(w, r, "index.html", data)
 }
 
 // About gère la route `/about` et rend la page statique "À propos".
 func About(w http.ResponseWriter, r *http.Request) {
 	data := PageData{
-		Title:   "À propos",
-		Message: "Ceci est la page à propos",
+		Title:   tr(r, "about.title"),
+		Message: tr(r, "about.message"),
+		Meta:    pageMeta(r, "meta.about"),
 	}
-	renderTemplate(w, "about.html", data)
+	renderTemplate(w, r, "about.html", data)
 }
 
-// Contact gère la route `/contact`.
-// Pour une requête POST, elle lit les champs du formulaire (`name`, `msg`)
-// et affiche un message de remerciement. Pour GET, elle affiche le
-// formulaire de contact sans message.
-func Contact(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodPost {
-		name := r.FormValue("name")
-		msg := r.FormValue("msg")
-
-		data := PageData{
-			Title:   "Contact",
-			Message: "Merci " + name + " pour ton message : " + msg,
-		}
-		renderTemplate(w, "contact.html", data)
-		return
-	}
-
-	data := PageData{
-		Title:   "Contact",
-		Message: "Envoie-nous un message",
-	}
-	renderTemplate(w, "contact.html", data)
+// clubQueryFromRequest lit les filtres `search`, `minYear`, `maxYear`,
+// `country` (pays exact), `venue` (partie du nom du stade) et le tri
+// `sort` (name, shortName, founded) / `order` (asc, desc) de la requête.
+// Les années invalides sont ignorées ; un tri ou un ordre inconnu est
+// signalé par une erreur et remplacé par les valeurs par défaut.
+func clubQueryFromRequest(r *http.Request) (storage.ClubQuery, error) {
+	q := storage.ClubQuery{
+		Search:  r.URL.Query().Get("search"),
+		Country: strings.TrimSpace(r.URL.Query().Get("country")),
+		Venue:   strings.TrimSpace(r.URL.Query().Get("venue")),
+	}
+	if min, err := strconv.Atoi(r.URL.Query().Get("minYear")); err == nil {
+		q.MinYear = min
+	}
+	if max, err := strconv.Atoi(r.URL.Query().Get("maxYear")); err == nil {
+		q.MaxYear = max
+	}
+
+	var err error
+	if sort := r.URL.Query().Get("sort"); storage.ValidSort(sort) {
+		q.Sort = sort
+	} else {
+		err = fmt.Errorf("invalid sort %q: expected name, shortName or founded", sort)
+	}
+	switch order := strings.ToLower(r.URL.Query().Get("order")); order {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		err = fmt.Errorf("invalid order %q: expected asc or desc", order)
+	}
+	return q, err
 }
 
-// SearchAndFilter fournit l'endpoint `/api/clubs` en JSON.
-// Elle charge tous les clubs, lit les paramètres de requête
-// (`search`, `minYear`, `maxYear`, `page`, `pageSize`), applique
-// les filtres de recherche et d'année, pagine les résultats,
-// et renvoie un objet JSON contenant les clubs paginés et les métadonnées.
-func SearchAndFilter(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+// homePageSize est le nombre de clubs par page sur la page d'accueil.
+const homePageSize = 12
 
-	clubs, err := models.LoadClubsFromFile("data/clubs.json")
-	if err != nil {
-		log.Printf("failed to load clubs: %v", err)
-		clubs = []models.Club{}
-	}
-
-	search := strings.ToLower(r.URL.Query().Get("search"))
-	minYear := r.URL.Query().Get("minYear")
-	maxYear := r.URL.Query().Get("maxYear")
-	pageStr := r.URL.Query().Get("page")
-	pageSizeStr := r.URL.Query().Get("pageSize")
-
-	page := 1
-	pageSize := 6
-	if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+// pageParams lit les paramètres `page` (1 par défaut) et `pageSize`
+// (`defaultSize` par défaut, 50 au maximum) de la requête.
+func pageParams(r *http.Request, defaultSize int) (page, pageSize int) {
+	page, pageSize = 1, defaultSize
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
 		page = p
 	}
-	if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 50 {
+	if ps, err := strconv.Atoi(r.URL.Query().Get("pageSize")); err == nil && ps > 0 && ps <= 50 {
 		pageSize = ps
 	}
+	return page, pageSize
+}
 
-	filtered := []models.Club{}
-	for _, club := range clubs {
-		// Search filter
-		if search != "" {
-			if !strings.Contains(strings.ToLower(club.Name), search) &&
-				!strings.Contains(strings.ToLower(club.ShortName), search) &&
-				!strings.Contains(strings.ToLower(club.TLA), search) {
-				continue
-			}
-		}
+// pageURL renvoie l'URL de la page `page` de la liste courante : tous les
+// paramètres de `r` (recherche, années, tri, taille de page) sont
+// conservés, seul `page` change (et disparaît pour la première page).
+func pageURL(r *http.Request, page int) string {
+	q := r.URL.Query()
+	if page <= 1 {
+		q.Del("page")
+	} else {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if len(q) == 0 {
+		return r.URL.Path
+	}
+	return r.URL.Path + "?" + q.Encode()
+}
 
-		if minYear != "" {
-			if min, err := strconv.Atoi(minYear); err == nil && club.Founded < min {
-				continue
-			}
-		}
-		if maxYear != "" {
-			if max, err := strconv.Atoi(maxYear); err == nil && club.Founded > max {
-				continue
-			}
+// paginationWindow est le nombre de pages proposées de part et d'autre
+// de la page courante.
+const paginationWindow = 2
+
+// setPagination renseigne les champs de pagination de `data` pour la page
+// `page` d'une liste de `total` clubs. `Pages` ne contient que la première
+// et la dernière page et celles proches de la page courante (voir
+// `paginationWindow`), avec un 0 à la place de chaque plage omise.
+func (data *PageData) setPagination(page, pageSize, total int) {
+	data.CurrentPage, data.PageSize, data.TotalClubs = page, pageSize, total
+	data.TotalPages = (total + pageSize - 1) / pageSize
+	data.PrevPage, data.NextPage, data.Pages = 0, 0, nil
+	if page > 1 {
+		data.PrevPage = page - 1
+	}
+	if page < data.TotalPages {
+		data.NextPage = page + 1
+	}
+	for p := 1; p <= data.TotalPages; p++ {
+		if p == 1 || p == data.TotalPages || (p >= page-paginationWindow && p <= page+paginationWindow) {
+			data.Pages = append(data.Pages, p)
+		} else if last := len(data.Pages) - 1; data.Pages[last] != 0 {
+			data.Pages = append(data.Pages, 0)
 		}
+	}
+}
 
-		filtered = append(filtered, club)
+// sortOrder renvoie "asc" ou "desc" pour l'exposer à l'API et aux templates.
+func sortOrder(q storage.ClubQuery) string {
+	if q.Desc {
+		return "desc"
 	}
+	return "asc"
+}
 
-	total := len(filtered)
-	totalPages := (total + pageSize - 1) / pageSize
+// SearchAndFilter fournit l'endpoint `/api/clubs` en JSON.
+// Elle lit les paramètres de requête
+// (`search`, `minYear`, `maxYear`, `country`, `venue`, `sort`, `order`,
+// `page`, `pageSize`, `fields`), applique les filtres de recherche,
+// d'année, de pays et de stade, trie et pagine les résultats (un tri
+// invalide renvoie une erreur 400), et renvoie un objet JSON contenant les
+// clubs paginés, les métadonnées et la liste des pays (`countries`).
+// Le paramètre `fields` (ex: `fields=id,name,crestUrl`) limite les champs
+// sérialisés pour chaque club afin d'alléger la réponse.
+func SearchAndFilter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	// Pagination
-	start := (page - 1) * pageSize
-	end := start + pageSize
-	if start > total {
-		start = total
+	page, pageSize := pageParams(r, 6)
+
+	// Les filtres et la pagination sont délégués au dépôt (SQL si la base
+	// SQLite est active, en mémoire sinon).
+	query, err := clubQueryFromRequest(r)
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
 	}
-	if end > total {
-		end = total
+	query.Offset = (page - 1) * pageSize
+	query.Limit = pageSize
+	paged, total, err := clubRepo.Search(query)
+	if err != nil {
+		log.Printf("failed to search clubs: %v", err)
+		paged, total = []models.Club{}, 0
 	}
+	totalPages := (total + pageSize - 1) / pageSize
 
-	paged := filtered[start:end]
+	var clubsOut interface{} = paged
+	if fields := r.URL.Query().Get("fields"); fields != "" {
+		clubsOut = selectFields(paged, fields)
+	}
 
 	response := FilterResponse{
-		Clubs:      paged,
+		Clubs:      clubsOut,
 		Total:      total,
 		Page:       page,
 		PageSize:   pageSize,
 		TotalPages: totalPages,
+		Sort:       query.Sort,
+		Order:      sortOrder(query),
+		Countries:  countries(),
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
 
+// countries renvoie les pays distincts des clubs pour le filtre `country`
+// (liste vide si le dépôt ne peut pas être lu).
+func countries() []string {
+	list, err := clubRepo.Countries()
+	if err != nil {
+		log.Printf("failed to load countries: %v", err)
+		return []string{}
+	}
+	return list
+}
+
 // GetFavoritesFromCookie lit les favoris depuis le cookie "favorites" de l'utilisateur.
 // Le cookie contient une liste d'IDs de clubs séparés par des virgules (ex: "12,34,56").
 // Cette fonction gère les cas où le cookie n'existe pas ou est vide en renvoyant
@@ -237,14 +361,37 @@ func GetFavoritesFromCookie(r *http.Request) []string {
 	return strings.Split(cookie.Value, ",")
 }
 
+// getFavorites renvoie les IDs des clubs favoris du visiteur : ceux de
+// son compte s'il est connecté, sinon ceux du cookie "favorites".
+func getFavorites(r *http.Request) []string {
+	if user := currentUser(r); user != nil {
+		ids, err := authStore.Favorites(user.ID)
+		if err == nil {
+			return ids
+		}
+		log.Printf("failed to load favorites for user %d: %v", user.ID, err)
+	}
+	return GetFavoritesFromCookie(r)
+}
+
+// favoriteIDSet renvoie les IDs des favoris (voir `getFavorites`) sous forme
+// de map, pour savoir rapidement si un club est favori (utile dans les templates).
+func favoriteIDSet(r *http.Request) map[string]bool {
+	set := make(map[string]bool)
+	for _, id := range getFavorites(r) {
+		set[id] = true
+	}
+	return set
+}
+
 // AddFavorite ajoute un club aux favoris.
 // Attendu: requête HTTP POST avec le champ de formulaire `club_id`.
 // Comportement:
 //   - Valide que la méthode est POST et que `club_id` est fourni.
-//   - Lit le cookie `favorites` existant (liste d'IDs séparés par des virgules).
-//   - Si l'ID n'est pas déjà présent, l'ajoute à la liste et remet à jour le cookie
-//     avec une durée de vie de 30 jours.
-//   - Redirige ensuite vers la page précédente (en utilisant l'en-tête Referer)
+//   - Ajoute le club via `addFavorite` (compte de l'utilisateur connecté,
+//     sinon cookie `favorites` d'une durée de vie de 30 jours).
+//   - Répond en JSON si la requête le demande (voir `wantsJSON`), sinon
+//     redirige vers la page précédente (en utilisant l'en-tête Referer)
 //     ou vers l'URL par défaut fournie.
 func AddFavorite(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -254,31 +401,19 @@ func AddFavorite(w http.ResponseWriter, r *http.Request) {
 
 	clubID := r.FormValue("club_id")
 	if clubID == "" {
-		redirectBack(w, r, "/")
-		return
-	}
-
-	favorites := GetFavoritesFromCookie(r)
-
-	// Vérifier si le club n'est pas déjà dans les favoris
-	for _, fav := range favorites {
-		if fav == clubID {
-			redirectBack(w, r, "/")
+		if wantsJSON(r) {
+			RenderError(w, r, http.StatusBadRequest, "club_id is required", nil)
 			return
 		}
+		redirectBack(w, r, "/")
+		return
 	}
 
-	favorites = append(favorites, clubID)
-
-	cookie := &http.Cookie{
-		Name:     "favorites",
-		Value:    strings.Join(favorites, ","),
-		Path:     "/",
-		MaxAge:   30 * 24 * 60 * 60, // 30 jours
-		HttpOnly: false,
+	favorites, _, err := addFavorite(w, r, clubID)
+	if wantsJSON(r) {
+		writeFavorites(w, r, http.StatusOK, favorites, err)
+		return
 	}
-	http.SetCookie(w, cookie)
-
 	redirectBack(w, r, "/")
 }
 
@@ -286,11 +421,10 @@ func AddFavorite(w http.ResponseWriter, r *http.Request) {
 // Attendu: requête HTTP POST avec le champ de formulaire `club_id`.
 // Comportement:
 //   - Valide que la méthode est POST et que `club_id` est fourni.
-//   - Lit le cookie `favorites`, retire l'ID fourni s'il y est présent,
-//     puis réécrit le cookie avec la nouvelle liste.
-//   - La durée du cookie reste identique (30 jours) ; si la liste devient vide,
-//     le cookie est mis à jour en conséquence.
-//   - Redirige ensuite vers la page précédente (Referer) ou vers l'URL par défaut.
+//   - Retire le club via `removeFavorite` (compte de l'utilisateur connecté,
+//     sinon cookie `favorites` réécrit avec la nouvelle liste).
+//   - Répond en JSON si la requête le demande (voir `wantsJSON`), sinon
+//     redirige vers la page précédente (Referer) ou vers l'URL par défaut.
 func RemoveFavorite(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		redirectBack(w, r, "/")
@@ -299,28 +433,19 @@ func RemoveFavorite(w http.ResponseWriter, r *http.Request) {
 
 	clubID := r.FormValue("club_id")
 	if clubID == "" {
+		if wantsJSON(r) {
+			RenderError(w, r, http.StatusBadRequest, "club_id is required", nil)
+			return
+		}
 		redirectBack(w, r, "/")
 		return
 	}
 
-	favorites := GetFavoritesFromCookie(r)
-	newFavorites := []string{}
-
-	for _, fav := range favorites {
-		if fav != clubID {
-			newFavorites = append(newFavorites, fav)
-		}
-	}
-
-	cookie := &http.Cookie{
-		Name:     "favorites",
-		Value:    strings.Join(newFavorites, ","),
-		Path:     "/",
-		MaxAge:   30 * 24 * 60 * 60,
-		HttpOnly: false,
+	favorites, _, err := removeFavorite(w, r, clubID)
+	if wantsJSON(r) {
+		writeFavorites(w, r, http.StatusOK, favorites, err)
+		return
 	}
-	http.SetCookie(w, cookie)
-
 	redirectBack(w, r, "/")
 }
 
@@ -339,61 +464,44 @@ func redirectBack(w http.ResponseWriter, r *http.Request, defaultURL string) {
 // HomeWithFavorites affiche la page d'accueil en tenant compte des favoris
 // et des paramètres de recherche/filtres passés par la requête GET.
 // Étapes réalisées:
-//  1. Charge tous les clubs depuis `data/clubs.json`.
-//  2. Récupère les paramètres GET `search`, `minYear`, `maxYear` et applique
-//     les filtres côté serveur (recherche textuelle et plage d'années).
+//  1. Charge tous les clubs via `loadClubs` (API ou `data/clubs.json`).
+//  2. Récupère les paramètres GET `search`, `minYear`, `maxYear`, `country`,
+//     `venue` et applique les filtres via `clubRepo.Search` (recherche
+//     textuelle, plage d'années, pays et stade),
+//     puis ne garde que la page demandée (`page`, `pageSize`, 12 clubs par
+//     défaut). Une page au-delà de la dernière affiche la dernière page.
 //  3. Lit le cookie `favorites` et construit une map `FavoriteIDs` pour
 //     indiquer rapidement si un club est favori (utile dans le template).
 //  4. Prépare le `PageData` avec : les clubs filtrés, la liste des favoris,
-//     la map des IDs favoris et les valeurs de recherche pour pré-remplir le formulaire.
+//     la map des IDs favoris, les valeurs de recherche pour pré-remplir le
+//     formulaire et la pagination (liens construits par la fonction `pageURL`
+//     des templates, qui conserve les filtres).
 //  5. Rend le template `index.html`.
 func HomeWithFavorites(w http.ResponseWriter, r *http.Request) {
-	clubs, err := models.LoadClubsFromFile("data/clubs.json")
-	if err != nil {
-		log.Printf("failed to load clubs: %v", err)
-		clubs = []models.Club{}
-	}
+	clubs := loadClubs()
 
 	// Récupérer les paramètres de recherche
 	search := strings.ToLower(r.URL.Query().Get("search"))
 	minYearStr := r.URL.Query().Get("minYear")
 	maxYearStr := r.URL.Query().Get("maxYear")
 
-	// Filtrer les clubs
-	filteredClubs := []models.Club{}
-	for _, club := range clubs {
-		// Search filter
-		if search != "" {
-			if !strings.Contains(strings.ToLower(club.Name), search) &&
-				!strings.Contains(strings.ToLower(club.ShortName), search) &&
-				!strings.Contains(strings.ToLower(club.TLA), search) {
-				continue
-			}
-		}
-
-		// Min year filter
-		if minYearStr != "" {
-			if minYear, err := strconv.Atoi(minYearStr); err == nil && club.Founded < minYear {
-				continue
-			}
-		}
-
-		// Max year filter
-		if maxYearStr != "" {
-			if maxYear, err := strconv.Atoi(maxYearStr); err == nil && club.Founded > maxYear {
-				continue
-			}
-		}
-
-		filteredClubs = append(filteredClubs, club)
+	// Filtrer, trier et paginer les clubs (un tri invalide revient à l'ordre par défaut)
+	query, _ := clubQueryFromRequest(r)
+	page, pageSize := pageParams(r, homePageSize)
+	query.Offset, query.Limit = (page-1)*pageSize, pageSize
+	filteredClubs, total, err := clubRepo.Search(query)
+	if err == nil && len(filteredClubs) == 0 && total > 0 {
+		page = (total + pageSize - 1) / pageSize
+		query.Offset = (page - 1) * pageSize
+		filteredClubs, total, err = clubRepo.Search(query)
+	}
+	if err != nil {
+		log.Printf("failed to search clubs: %v", err)
+		filteredClubs, total = []models.Club{}, 0
 	}
 
 	// Récupérer les IDs des favoris
-	favoriteIDs := GetFavoritesFromCookie(r)
-	favoriteIDMap := make(map[string]bool)
-	for _, id := range favoriteIDs {
-		favoriteIDMap[id] = true
-	}
+	favoriteIDMap := favoriteIDSet(r)
 
 	// Construire la liste des clubs favoris
 	favorites := []models.Club{}
@@ -404,38 +512,42 @@ func HomeWithFavorites(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := PageData{
-		Title:       "Accueil",
-		Message:     "Bienvenue sur la page d'accueil",
+		Title:       tr(r, "home.title"),
+		Message:     tr(r, "home.message"),
 		Clubs:       filteredClubs,
 		Favorites:   favorites,
 		FavoriteIDs: favoriteIDMap,
+		User:        currentUser(r),
 		SearchQuery: search,
 		MinYear:     minYearStr,
 		MaxYear:     maxYearStr,
-	}
-	renderTemplate(w, "index.html", data)
+		Country:     query.Country,
+		Venue:       query.Venue,
+		Countries:   countries(),
+		Sort:        query.Sort,
+		Order:       sortOrder(query),
+		Meta:        pageMeta(r, "meta.home"),
+	}
+	data.setPagination(page, pageSize, total)
+	if page > 1 {
+		data.Meta.Canonical = fmt.Sprintf("/?page=%d", page)
+	}
+	renderTemplate(w, r, "index.html", data)
 }
 
 // Favorites affiche la page listant uniquement les clubs marqués comme favoris.
 // Fonctionnement:
-//   - Charge tous les clubs depuis `data/clubs.json`.
+//   - Charge tous les clubs via `loadClubs` (API ou `data/clubs.json`).
 //   - Lit le cookie `favorites` et construit une map d'IDs favorisés.
 //   - Construit la slice `favorites` contenant les objets `models.Club`
 //     correspondant aux IDs favoris.
-//   - Rend le template `favorites.html` avec `PageData.Favorites`.
+//   - Rend le template `favorites.html` avec `PageData.Favorites` et le
+//     lien de partage `PageData.ShareURL` (voir `/favorites/share`).
 func Favorites(w http.ResponseWriter, r *http.Request) {
-	clubs, err := models.LoadClubsFromFile("data/clubs.json")
-	if err != nil {
-		log.Printf("failed to load clubs: %v", err)
-		clubs = []models.Club{}
-	}
+	clubs := loadClubs()
 
 	// Récupérer les IDs des favoris
-	favoriteIDs := GetFavoritesFromCookie(r)
-	favoriteIDMap := make(map[string]bool)
-	for _, id := range favoriteIDs {
-		favoriteIDMap[id] = true
-	}
+	favoriteIDMap := favoriteIDSet(r)
 
 	// Construire la liste des clubs favoris
 	favorites := []models.Club{}
@@ -446,32 +558,143 @@ func Favorites(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := PageData{
-		Title:       "Mes Favoris",
-		Message:     "Vos clubs favoris",
+		Title:       tr(r, "favorites.title"),
+		Message:     tr(r, "favorites.message"),
 		Favorites:   favorites,
 		FavoriteIDs: favoriteIDMap,
+		User:        currentUser(r),
+		Meta:        Meta{NoIndex: true},
 	}
-	renderTemplate(w, "favorites.html", data)
+	if len(favorites) > 0 {
+		data.ShareURL = shareURL(r, encodeShareToken(getFavorites(r)))
+	}
+	renderTemplate(w, r, "favorites.html", data)
 }
 
 // ClearFavorites supprime tous les favoris enregistrés pour l'utilisateur.
-// Attendu: requête POST. La fonction réinitialise le cookie `favorites`
-// en le vidant (MaxAge=-1) pour effacer la valeur côté client, puis
-// redirige vers la page `/favorites`.
+// Attendu: requête POST. Les favoris sont supprimés via `clearFavorites`
+// (compte de l'utilisateur connecté et cookie `favorites`), puis la
+// fonction répond en JSON si la requête le demande, ou redirige vers
+// la page `/favorites`.
 func ClearFavorites(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/favorites", http.StatusSeeOther)
 		return
 	}
 
-	cookie := &http.Cookie{
-		Name:     "favorites",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: false,
+	err := clearFavorites(w, r)
+	if wantsJSON(r) {
+		writeFavorites(w, r, http.StatusOK, nil, err)
+		return
 	}
-	http.SetCookie(w, cookie)
-
 	http.Redirect(w, r, "/favorites", http.StatusSeeOther)
 }
+
+// SearchAPI fournit l'endpoint `/api/search` en JSON.
+// Elle lit le paramètre `q` (et `limit`, 20 par défaut, 50 au maximum),
+// recherche parmi les clubs, les stades et les joueurs via
+// `models.SearchAll` et renvoie une liste de résultats typés classés par
+// pertinence.
+func SearchAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	clubs := loadClubs()
+
+	query := r.URL.Query().Get("q")
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 50 {
+		limit = l
+	}
+
+	results := models.SearchAll(clubs, loadPlayers(), query)
+	total := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	json.NewEncoder(w).Encode(SearchResponse{
+		Query:   query,
+		Results: results,
+		Total:   total,
+	})
+}
+
+// SuggestAPI fournit l'endpoint `/api/clubs/suggest` utilisé par
+// l'autocomplétion du champ de recherche. Elle lit `q` (et `limit`, 8 par
+// défaut, 20 au maximum) et renvoie des suggestions allégées (id, nom,
+// blason) calculées par l'index du dépôt de clubs : préfixes d'abord,
+// puis correspondances approchées tolérant les fautes de frappe.
+func SuggestAPI(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	limit := 8
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 20 {
+		limit = l
+	}
+
+	suggestions, err := clubRepo.Suggest(query, limit)
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "cannot load clubs", fmt.Errorf("suggest failed: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(SuggestResponse{
+		Query:       query,
+		Suggestions: suggestions,
+	})
+}
+
+// Search affiche la page de résultats de la recherche globale `/search?q=`.
+// Les résultats (clubs, stades et joueurs) sont calculés par `models.SearchAll`
+// et rendus dans le template `search.html`.
+func Search(w http.ResponseWriter, r *http.Request) {
+	clubs := loadClubs()
+
+	query := r.URL.Query().Get("q")
+	data := PageData{
+		Title:       tr(r, "search.title"),
+		Message:     tr(r, "search.results", query),
+		SearchQuery: query,
+		Results:     models.SearchAll(clubs, loadPlayers(), query),
+		// Les pages de résultats ne sont pas indexées, seulement le formulaire.
+		Meta: Meta{Description: tr(r, "meta.search"), NoIndex: query != ""},
+	}
+	renderTemplate(w, r, "search.html", data)
+}
+
+// ClubDetail gère la route `/club/{id}` et affiche la fiche d'un club
+// (blason, stade, année de fondation, site web, effectif) avec un bouton
+// pour l'ajouter ou le retirer des favoris.
+// Si l'ID n'est pas un entier ou ne correspond à aucun club, une page
+// 404 est rendue via `NotFound` ; une erreur de chargement renvoie 500.
+func ClubDetail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		NotFound(w, r)
+		return
+	}
+
+	club, err := clubRepo.ByID(id)
+	if errors.Is(err, models.ErrClubNotFound) {
+		NotFound(w, r)
+		return
+	}
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to load club %d: %w", id, err))
+		return
+	}
+
+	favoriteIDMap := favoriteIDSet(r)
+
+	data := PageData{
+		Title:       club.Name,
+		Message:     club.ShortName,
+		Club:        club,
+		FavoriteIDs: favoriteIDMap,
+		IsFavorite:  favoriteIDMap[strconv.Itoa(club.ID)],
+		Players:     loadSquad(club.ID),
+		Meta:        clubMeta(r, club),
+	}
+	renderTemplate(w, r, "club.html", data)
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetPagination(t *testing.T) {
+	tests := []struct {
+		name      string
+		page      int
+		total     int
+		wantPages []int
+		prev      int
+		next      int
+	}{
+		{"single page", 1, 5, []int{1}, 0, 0},
+		{"few pages", 2, 50, []int{1, 2, 3, 4, 5}, 1, 3},
+		{"first of many", 1, 500, []int{1, 2, 3, 0, 50}, 0, 2},
+		{"middle of many", 25, 500, []int{1, 0, 23, 24, 25, 26, 27, 0, 50}, 24, 26},
+		{"last of many", 50, 500, []int{1, 0, 48, 49, 50}, 49, 0},
+		{"no clubs", 1, 0, nil, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data PageData
+			data.setPagination(tt.page, 10, tt.total)
+			if !reflect.DeepEqual(data.Pages, tt.wantPages) {
+				t.Errorf("Pages = %v, want %v", data.Pages, tt.wantPages)
+			}
+			if data.PrevPage != tt.prev || data.NextPage != tt.next {
+				t.Errorf("prev/next = %d/%d, want %d/%d", data.PrevPage, data.NextPage, tt.prev, tt.next)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"groupie_tracker/crests"
+	"groupie_tracker/models"
+)
+
+// crestCache fournit les blasons servis par `/crests/{clubID}` ; nil tant
+// que `SetCrests` n'a pas été appelée (les blasons de repli sont alors
+// servis).
+var crestCache *crests.Cache
+
+// SetCrests définit le cache des blasons.
+func SetCrests(c *crests.Cache) {
+	crestCache = c
+}
+
+// crestSVGPolicy interdit aux SVG servis d'exécuter des scripts ou de
+// charger d'autres ressources s'ils sont ouverts directement.
+const crestSVGPolicy = "default-src 'none'; style-src 'unsafe-inline'; sandbox"
+
+// StaticFiles sert les fichiers de `fsys` sous `/static/`. Les SVG
+// (blasons fournis, ou envoyés avant que l'administration ne les refuse)
+// reçoivent la même politique que ceux de `/crests/`.
+func StaticFiles(fsys fs.FS) http.Handler {
+	files := http.StripPrefix("/static/", http.FileServerFS(fsys))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(path.Ext(r.URL.Path), ".svg") {
+			w.Header().Set("Content-Security-Policy", crestSVGPolicy)
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+		}
+		files.ServeHTTP(w, r)
+	})
+}
+
+// crestURL renvoie l'URL du blason de `club` à la taille `size`. Le
+// paramètre `v` change avec l'URL source du blason : les navigateurs
+// peuvent garder l'image en cache longtemps sans rater un remplacement.
+func crestURL(club models.Club, size int) string {
+	return fmt.Sprintf("/crests/%d?size=%d&v=%s", club.ID, size, crests.Version(club.CrestURL))
+}
+
+// CrestImage gère la route `/crests/{clubID}` et sert le blason du club
+// redimensionné (paramètre `size`, 64 ou 256 pixels, 256 par défaut).
+// Le blason est récupéré une seule fois puis servi depuis le cache disque
+// avec un `Cache-Control` d'une semaine. Si le club n'a pas de blason ou
+// si l'image source est indisponible, un blason de repli en SVG portant le
+// TLA du club est servi, avec une durée de cache courte.
+// Un club inconnu renvoie 404, une taille non standard 400.
+func CrestImage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("clubID"))
+	if err != nil {
+		NotFound(w, r)
+		return
+	}
+	size := crests.DefaultSize
+	if v := r.URL.Query().Get("size"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || !crests.ValidSize(size) {
+			RenderError(w, r, http.StatusBadRequest, "size must be one of 64, 256", nil)
+			return
+		}
+	}
+	club, err := clubRepo.ByID(id)
+	if err != nil {
+		if !errors.Is(err, models.ErrClubNotFound) {
+			RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to load clubs: %w", err))
+			return
+		}
+		NotFound(w, r)
+		return
+	}
+
+	if club.CrestURL != "" && crestCache != nil {
+		img, err := crestCache.Get(r.Context(), club.CrestURL, size)
+		if err == nil {
+			h := w.Header()
+			h.Set("Content-Type", img.ContentType)
+			h.Set("Cache-Control", "public, max-age=604800")
+			h.Set("ETag", img.ETag)
+			if img.ContentType == "image/svg+xml" {
+				h.Set("Content-Security-Policy", crestSVGPolicy)
+			}
+			http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(img.Data))
+			return
+		}
+		log.Printf("crest of club %d unavailable: %v", club.ID, err)
+	}
+
+	label := club.TLA
+	if label == "" && club.ShortName != "" {
+		label = string([]rune(club.ShortName)[:1])
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Content-Security-Policy", crestSVGPolicy)
+	w.Write(crests.Placeholder(label))
+}
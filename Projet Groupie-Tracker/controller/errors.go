@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorResponse est le corps JSON des erreurs : `{"error": msg, "status": 404}`.
+type ErrorResponse struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+// wantsJSONError indique si une erreur doit être rendue en JSON : routes
+// `/api/*`, ou client qui attend du JSON (voir `wantsJSON`).
+func wantsJSONError(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/") || wantsJSON(r)
+}
+
+// RenderError répond une erreur HTTP `status` et centralise leur rendu :
+//   - `err` (détail interne : chemin de fichier, erreur SQL, de template...)
+//     est seulement journalisé, jamais envoyé au client ;
+//   - `publicMsg` est le message affiché ; vide, il est remplacé par le
+//     message générique du statut (traduit pour les pages HTML) ;
+//   - les routes `/api/*` et les clients qui attendent du JSON reçoivent
+//     un `ErrorResponse`, les autres une page `notfound.html` (404) ou
+//     `error.html`.
+//
+// Si la page d'erreur elle-même ne peut pas être rendue, le client reçoit
+// le message en texte brut.
+func RenderError(w http.ResponseWriter, r *http.Request, status int, publicMsg string, err error) {
+	if err != nil {
+		log.Printf("%s %s: %d %s: %v", r.Method, r.URL.Path, status, http.StatusText(status), err)
+	}
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Del("Content-Disposition")
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Content-Type-Options", "nosniff")
+
+	if wantsJSONError(r) {
+		if publicMsg == "" {
+			publicMsg = strings.ToLower(http.StatusText(status))
+		}
+		writeJSONError(w, status, publicMsg)
+		return
+	}
+
+	if publicMsg == "" {
+		publicMsg = errorMessage(r, status)
+	}
+	name, data := "error.html", PageData{
+		Title:   tr(r, "error.title", status),
+		Message: publicMsg,
+		Status:  status,
+		User:    currentUser(r),
+		Meta:    Meta{NoIndex: true},
+	}
+	if status == http.StatusNotFound {
+		name, data.Title = "notfound.html", tr(r, "notfound.title")
+	}
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	if err := executeTemplate(w, r, status, name, data); err != nil {
+		log.Printf("cannot render error page: %v", err)
+		h.Del("Content-Type")
+		http.Error(w, publicMsg, status)
+	}
+}
+
+// errorMessage renvoie le message générique traduit du statut `status`
+// (clé `error.<status>`) ; les statuts sans traduction reprennent celui
+// de 500 pour les erreurs serveur, le texte standard sinon.
+func errorMessage(r *http.Request, status int) string {
+	key := "error." + strconv.Itoa(status)
+	if msg := tr(r, key); msg != key {
+		return msg
+	}
+	if status >= http.StatusInternalServerError {
+		return tr(r, "error.500")
+	}
+	return http.StatusText(status)
+}
+
+// writeJSONError écrit une erreur `ErrorResponse` avec le statut donné.
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: msg, Status: status})
+}
+
+// NotFound rend la page `notfound.html` (ou une erreur JSON pour l'API)
+// avec le statut HTTP 404. C'est aussi le handler des routes inconnues.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	RenderError(w, r, http.StatusNotFound, "", nil)
+}
+
+// methodNotAllowed répond 405 en indiquant les méthodes acceptées.
+func methodNotAllowed(w http.ResponseWriter, r *http.Request, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	RenderError(w, r, http.StatusMethodNotAllowed, "", nil)
+}
+
+// setRetryAfter écrit l'en-tête Retry-After d'une réponse 429 : `d`
+// arrondi à la seconde supérieure.
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int((d+time.Second-1)/time.Second)))
+}
@@ -0,0 +1,369 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"groupie_tracker/models"
+	"groupie_tracker/ratelimit"
+)
+
+// maxTryBody limite la taille de la réponse affichée par « Essayer ».
+const maxTryBody = 256 << 10
+
+// tryLimiter limite les requêtes « Essayer » de chaque compte : elles
+// consomment le quota de la clé d'API du serveur.
+var tryLimiter = ratelimit.New(30, time.Hour)
+
+// ExplorerConfig décrit la collection Postman affichée par `/api-explorer`
+// et l'accès à l'API utilisé pour exécuter ses requêtes.
+type ExplorerConfig struct {
+	// CollectionFile est le fichier de la collection (`data.json`).
+	CollectionFile string
+	// Variables remplace des variables de la collection (ex: "url" par
+	// l'URL de l'API configurée).
+	Variables map[string]string
+	// APIKey est envoyée dans l'en-tête d'authentification de la
+	// collection ; elle n'est jamais affichée.
+	APIKey string
+	// Client exécute les requêtes ; s'il est nil, un client avec un délai
+	// de 10 s est utilisé.
+	Client *http.Client
+}
+
+var explorer = struct {
+	sync.Mutex
+	cfg ExplorerConfig
+}{cfg: ExplorerConfig{CollectionFile: "data.json"}}
+
+// SetExplorer définit la collection et l'accès à l'API de `/api-explorer`.
+func SetExplorer(cfg ExplorerConfig) {
+	explorer.Lock()
+	defer explorer.Unlock()
+	explorer.cfg = cfg
+}
+
+// Explorer est la collection préparée pour le template `api_explorer.html`.
+type Explorer struct {
+	Name       string
+	Variables  []models.Variable // variables résolues
+	AuthHeader string            // en-tête d'authentification ("" si aucun)
+	HasAPIKey  bool
+	Folders    []ExplorerFolder
+	Try        *TryResult // résultat de la dernière requête exécutée
+	CanTry     bool       // le visiteur peut exécuter les requêtes (voir canTryAPI)
+}
+
+// ExplorerFolder regroupe les requêtes d'un dossier de la collection
+// (Name est vide pour les requêtes à la racine).
+type ExplorerFolder struct {
+	Name     string
+	Requests []ExplorerRequest
+}
+
+// ExplorerRequest est une requête de la collection, variables résolues.
+type ExplorerRequest struct {
+	ID          string
+	Name        string
+	Description string
+	Method      string
+	URL         string // sans les paramètres de requête
+	Headers     []models.Header
+	Params      []models.QueryParam
+}
+
+// FullURL renvoie l'URL avec les paramètres actifs.
+// Les paramètres gardent l'ordre de la collection.
+func (req ExplorerRequest) FullURL() string {
+	var q []string
+	for _, p := range req.Params {
+		if !p.Disabled {
+			q = append(q, url.QueryEscape(p.Key)+"="+url.QueryEscape(p.Value))
+		}
+	}
+	if len(q) == 0 {
+		return req.URL
+	}
+	return req.URL + "?" + strings.Join(q, "&")
+}
+
+// TryResult est la réponse de l'API à une requête exécutée depuis
+// l'explorateur.
+type TryResult struct {
+	RequestID   string
+	Method      string
+	URL         string
+	Status      string
+	StatusCode  int
+	Duration    time.Duration
+	ContentType string
+	Headers     []models.Header
+	Body        string
+	Truncated   bool
+	Error       string
+}
+
+// loadExplorer lit la collection et résout ses variables.
+func loadExplorer() (*Explorer, ExplorerConfig, error) {
+	explorer.Lock()
+	cfg := explorer.cfg
+	explorer.Unlock()
+
+	c, err := models.LoadCollectionFromFile(cfg.CollectionFile)
+	if err != nil {
+		return nil, cfg, err
+	}
+	vars := c.Variables()
+	for k, v := range cfg.Variables {
+		vars[k] = v
+	}
+	ex := &Explorer{Name: c.Info.Name, HasAPIKey: cfg.APIKey != ""}
+	for _, v := range c.Variable {
+		v.Value = vars[v.Key]
+		ex.Variables = append(ex.Variables, v)
+	}
+	if c.Auth != nil && c.Auth.Type == "apikey" && c.Auth.Apikey != nil {
+		ex.AuthHeader = c.Auth.Apikey.Key
+	}
+
+	// Les dossiers imbriqués sont aplatis ("Parent / Enfant"), dans
+	// l'ordre de la collection ; les requêtes à la racine viennent en tête.
+	var walk func(folder string, items []models.Item)
+	walk = func(folder string, items []models.Item) {
+		for _, it := range items {
+			switch {
+			case it.IsFolder():
+				name := it.Name
+				if folder != "" {
+					name = folder + " / " + it.Name
+				}
+				ex.Folders = append(ex.Folders, ExplorerFolder{Name: name})
+				walk(name, it.Item)
+			case it.Request != nil:
+				ex.addRequest(folder, explorerRequest(it, vars))
+			}
+		}
+	}
+	walk("", c.Item)
+	return ex, cfg, nil
+}
+
+// addRequest ajoute `req` au dossier `folder`, créé au besoin.
+func (ex *Explorer) addRequest(folder string, req ExplorerRequest) {
+	for i := range ex.Folders {
+		if ex.Folders[i].Name == folder {
+			ex.Folders[i].Requests = append(ex.Folders[i].Requests, req)
+			return
+		}
+	}
+	f := ExplorerFolder{Name: folder, Requests: []ExplorerRequest{req}}
+	if folder == "" {
+		ex.Folders = append([]ExplorerFolder{f}, ex.Folders...)
+		return
+	}
+	ex.Folders = append(ex.Folders, f)
+}
+
+// explorerRequest prépare la requête de `it` en résolvant les variables.
+func explorerRequest(it models.Item, vars map[string]string) ExplorerRequest {
+	r := it.Request
+	req := ExplorerRequest{
+		ID:          it.ID,
+		Name:        it.Name,
+		Description: it.Description,
+		Method:      strings.ToUpper(r.Method),
+		URL:         r.URL.Resolve(vars),
+	}
+	if req.ID == "" {
+		req.ID = it.Name
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	if req.Description == "" {
+		req.Description = r.Description
+	}
+	for _, h := range r.Header {
+		h.Value = models.ResolveVariables(h.Value, vars)
+		req.Headers = append(req.Headers, h)
+	}
+	for _, p := range r.URL.Params() {
+		p.Value = models.ResolveVariables(p.Value, vars)
+		req.Params = append(req.Params, p)
+	}
+	return req
+}
+
+// find renvoie la requête `id` de la collection.
+func (ex *Explorer) find(id string) (ExplorerRequest, bool) {
+	for _, f := range ex.Folders {
+		for _, req := range f.Requests {
+			if req.ID == id {
+				return req, true
+			}
+		}
+	}
+	return ExplorerRequest{}, false
+}
+
+// APIExplorer gère `/api-explorer` et affiche la collection Postman
+// (`data.json`) : dossiers, requêtes, méthodes, en-têtes et paramètres,
+// avec les variables `{{...}}` résolues. Chaque requête GET peut être
+// exécutée par le serveur avec `APIExplorerTry`.
+func APIExplorer(w http.ResponseWriter, r *http.Request) {
+	ex, _, err := loadExplorer()
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to load API collection: %w", err))
+		return
+	}
+	renderExplorer(w, r, ex)
+}
+
+// canTryAPI indique si le visiteur peut exécuter les requêtes de
+// l'explorateur : il doit être connecté (ou administrateur).
+func canTryAPI(r *http.Request) bool {
+	return currentUser(r) != nil || isAdmin(r)
+}
+
+// APIExplorerTry gère `POST /api-explorer/try` : le serveur exécute la
+// requête `id` de la collection (les valeurs des paramètres peuvent être
+// modifiées avec les champs `param.<nom>`), avec la clé d'API configurée,
+// puis ré-affiche l'explorateur avec la réponse. Seules les requêtes de
+// la collection peuvent être exécutées, et seulement en GET.
+// Le visiteur doit être connecté (sinon redirection vers `/login`) et
+// chaque compte est limité par `tryLimiter` (429) ; les administrateurs
+// ne sont pas limités.
+func APIExplorerTry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/api-explorer", http.StatusSeeOther)
+		return
+	}
+	if !canTryAPI(r) {
+		if authStore != nil {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape("/api-explorer"), http.StatusSeeOther)
+			return
+		}
+		RenderError(w, r, http.StatusForbidden, tr(r, "explorer.login"), nil)
+		return
+	}
+	if user := currentUser(r); user != nil && !isAdmin(r) {
+		if ok, retry := tryLimiter.Allow(strconv.FormatInt(user.ID, 10)); !ok {
+			setRetryAfter(w, retry)
+			RenderError(w, r, http.StatusTooManyRequests, "", nil)
+			return
+		}
+	}
+	ex, cfg, err := loadExplorer()
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to load API collection: %w", err))
+		return
+	}
+	req, ok := ex.find(r.FormValue("id"))
+	if !ok {
+		RenderError(w, r, http.StatusNotFound, tr(r, "explorer.unknown"), nil)
+		return
+	}
+	if req.Method != http.MethodGet {
+		RenderError(w, r, http.StatusBadRequest, tr(r, "explorer.get_only"), nil)
+		return
+	}
+	for i, p := range req.Params {
+		if v, ok := r.Form["param."+p.Key]; ok {
+			req.Params[i].Value = strings.TrimSpace(v[0])
+		}
+	}
+
+	ex.Try = tryRequest(r, req, ex.AuthHeader, cfg)
+	renderExplorer(w, r, ex)
+}
+
+// tryRequest exécute `req` et résume la réponse.
+func tryRequest(r *http.Request, req ExplorerRequest, authHeader string, cfg ExplorerConfig) *TryResult {
+	res := &TryResult{RequestID: req.ID, Method: req.Method, URL: req.FullURL()}
+	u, err := url.Parse(res.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		res.Error = fmt.Sprintf("URL invalide : %s", res.URL)
+		return res
+	}
+	out, err := http.NewRequestWithContext(r.Context(), req.Method, res.URL, nil)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	for _, h := range req.Headers {
+		if !h.Disabled {
+			out.Header.Set(h.Key, h.Value)
+		}
+	}
+	if authHeader != "" && cfg.APIKey != "" {
+		out.Header.Set(authHeader, cfg.APIKey)
+	}
+
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	start := time.Now()
+	resp, err := client.Do(out)
+	res.Duration = time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		res.Error = err.Error()
+		return res
+	}
+	defer resp.Body.Close()
+
+	res.Status, res.StatusCode = resp.Status, resp.StatusCode
+	res.ContentType = resp.Header.Get("Content-Type")
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		res.Headers = append(res.Headers, models.Header{Key: name, Value: strings.Join(resp.Header[name], ", ")})
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTryBody+1))
+	if err != nil {
+		res.Error = err.Error()
+	}
+	if len(body) > maxTryBody {
+		body, res.Truncated = body[:maxTryBody], true
+	}
+	if mt, _, _ := mime.ParseMediaType(res.ContentType); mt == "application/json" && !res.Truncated {
+		var pretty bytes.Buffer
+		if json.Indent(&pretty, body, "", "  ") == nil {
+			body = pretty.Bytes()
+		}
+	}
+	res.Body = string(body)
+	return res
+}
+
+// renderExplorer affiche l'explorateur `ex`.
+func renderExplorer(w http.ResponseWriter, r *http.Request, ex *Explorer) {
+	ex.CanTry = canTryAPI(r)
+	data := PageData{
+		Title:    tr(r, "explorer.title"),
+		Message:  tr(r, "explorer.message", ex.Name),
+		Explorer: ex,
+		User:     currentUser(r),
+		Meta:     pageMeta(r, "meta.explorer"),
+	}
+	renderTemplate(w, r, "api_explorer.html", data)
+}
@@ -0,0 +1,262 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"groupie_tracker/models"
+)
+
+// favoritesMaxAge est la durée de vie du cookie "favorites" (30 jours).
+const favoritesMaxAge = 30 * 24 * 60 * 60
+
+type FavoritesResponse struct {
+	Favorites []models.Club `json:"favorites"`
+	IDs       []string      `json:"ids"`
+	Total     int           `json:"total"`
+}
+
+// wantsJSON indique si le client attend une réponse JSON plutôt qu'une
+// redirection : en-tête `X-Requested-With: XMLHttpRequest` (fetch/XHR)
+// ou `Accept` contenant `application/json`.
+func wantsJSON(r *http.Request) bool {
+	return r.Header.Get("X-Requested-With") == "XMLHttpRequest" ||
+		strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// clientIP renvoie l'adresse IP du client de la connexion. Les en-têtes
+// `X-Forwarded-For` ne sont pas lus : ils peuvent être forgés.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// writeFavorites écrit la liste des favoris `ids` (convertie en clubs) en
+// JSON avec le statut donné, ou une erreur 500 si `err` n'est pas nil.
+func writeFavorites(w http.ResponseWriter, r *http.Request, status int, ids []string, err error) {
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "cannot update favorites", fmt.Errorf("favorites update failed: %w", err))
+		return
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	clubs := favoriteClubs(ids)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(FavoritesResponse{Favorites: clubs, IDs: ids, Total: len(ids)})
+}
+
+// setFavoritesCookie réécrit le cookie "favorites" avec la liste `ids`.
+// Le cookie n'est lu que côté serveur : il est HttpOnly, et SameSite=Lax
+// évite qu'il soit envoyé par les requêtes POST d'autres sites.
+func setFavoritesCookie(w http.ResponseWriter, ids []string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "favorites",
+		Value:    strings.Join(ids, ","),
+		Path:     "/",
+		MaxAge:   favoritesMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearFavoritesCookie supprime le cookie "favorites" (MaxAge=-1).
+func clearFavoritesCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "favorites",
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// addFavorite ajoute `clubID` aux favoris du visiteur (compte s'il est
+// connecté, cookie sinon) et renvoie la nouvelle liste et si le club a
+// effectivement été ajouté (false s'il y était déjà).
+func addFavorite(w http.ResponseWriter, r *http.Request, clubID string) ([]string, bool, error) {
+	favorites := getFavorites(r)
+	for _, fav := range favorites {
+		if fav == clubID {
+			return favorites, false, nil
+		}
+	}
+	favorites = append(favorites, clubID)
+
+	if user := currentUser(r); user != nil {
+		if err := authStore.AddFavorites(user.ID, clubID); err != nil {
+			return nil, false, err
+		}
+		return favorites, true, nil
+	}
+	setFavoritesCookie(w, favorites)
+	return favorites, true, nil
+}
+
+// mergeFavorites ajoute les clubs `ids` aux favoris du visiteur (compte
+// ou cookie) en ignorant ceux déjà présents, et renvoie la nouvelle liste.
+func mergeFavorites(w http.ResponseWriter, r *http.Request, ids []string) ([]string, error) {
+	favorites := getFavorites(r)
+	present := make(map[string]bool, len(favorites))
+	for _, fav := range favorites {
+		present[fav] = true
+	}
+	for _, id := range ids {
+		if !present[id] {
+			present[id] = true
+			favorites = append(favorites, id)
+		}
+	}
+
+	if user := currentUser(r); user != nil {
+		if err := authStore.AddFavorites(user.ID, ids...); err != nil {
+			return nil, err
+		}
+		return favorites, nil
+	}
+	setFavoritesCookie(w, favorites)
+	return favorites, nil
+}
+
+// removeFavorite retire `clubID` des favoris du visiteur et renvoie la
+// nouvelle liste et si le club était présent.
+func removeFavorite(w http.ResponseWriter, r *http.Request, clubID string) ([]string, bool, error) {
+	favorites := getFavorites(r)
+	newFavorites := []string{}
+	removed := false
+	for _, fav := range favorites {
+		if fav == clubID {
+			removed = true
+			continue
+		}
+		newFavorites = append(newFavorites, fav)
+	}
+
+	if user := currentUser(r); user != nil {
+		if err := authStore.RemoveFavorite(user.ID, clubID); err != nil {
+			return nil, false, err
+		}
+		return newFavorites, removed, nil
+	}
+	setFavoritesCookie(w, newFavorites)
+	return newFavorites, removed, nil
+}
+
+// clearFavorites supprime tous les favoris du visiteur : ceux du compte
+// s'il est connecté, et dans tous les cas le cookie "favorites" (MaxAge=-1).
+func clearFavorites(w http.ResponseWriter, r *http.Request) error {
+	var err error
+	if user := currentUser(r); user != nil {
+		err = authStore.ClearFavorites(user.ID)
+	}
+	clearFavoritesCookie(w)
+	return err
+}
+
+// maxFavoritesBody limite le corps des requêtes de `/api/favorites`.
+const maxFavoritesBody = 64 << 10
+
+// parseDeleteForm lit le corps `application/x-www-form-urlencoded` d'une
+// requête DELETE, que `http.Request.ParseForm` ignore pour cette méthode.
+// Comme pour POST, les valeurs du corps passent avant celles de l'URL.
+func parseDeleteForm(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if ct != "application/x-www-form-urlencoded" || r.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxFavoritesBody))
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	form := url.Values{}
+	for k, v := range values {
+		form[k] = append(form[k], v...)
+	}
+	for k, v := range r.Form {
+		form[k] = append(form[k], v...)
+	}
+	r.PostForm, r.Form = values, form
+	return nil
+}
+
+// FavoritesAPI fournit l'endpoint REST `/api/favorites` en JSON, sans
+// redirection, pour le front-end JavaScript:
+//   - GET    : liste des clubs favoris (200) ;
+//   - POST   : ajoute `club_id` (201 si ajouté, 200 s'il y était déjà) ;
+//   - DELETE : retire `club_id` (200, ou 404 s'il n'était pas favori).
+//
+// `club_id` est lu dans les paramètres de l'URL ou dans un corps
+// `application/x-www-form-urlencoded`, y compris pour DELETE. Il doit
+// désigner un club existant (400 s'il est absent ou invalide, 404 s'il
+// est inconnu). Les favoris sont lus et écrits au même endroit que pour
+// les formulaires (compte ou cookie "favorites").
+func FavoritesAPI(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		writeFavorites(w, r, http.StatusOK, getFavorites(r), nil)
+		return
+	case http.MethodPost, http.MethodDelete:
+	default:
+		methodNotAllowed(w, r, http.MethodGet, http.MethodPost, http.MethodDelete)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := parseDeleteForm(w, r); err != nil {
+			RenderError(w, r, http.StatusBadRequest, "cannot parse request body", err)
+			return
+		}
+	}
+	clubID := r.FormValue("club_id")
+	id, err := strconv.Atoi(clubID)
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, "club_id must be a club identifier", nil)
+		return
+	}
+	if _, err := clubRepo.ByID(id); err != nil {
+		if errors.Is(err, models.ErrClubNotFound) {
+			RenderError(w, r, http.StatusNotFound, "club not found", nil)
+			return
+		}
+		RenderError(w, r, http.StatusInternalServerError, "cannot load clubs", fmt.Errorf("failed to load club %d: %w", id, err))
+		return
+	}
+	clubID = strconv.Itoa(id)
+
+	if r.Method == http.MethodPost {
+		favorites, added, err := addFavorite(w, r, clubID)
+		status := http.StatusOK
+		if added {
+			status = http.StatusCreated
+		}
+		writeFavorites(w, r, status, favorites, err)
+		return
+	}
+
+	favorites, removed, err := removeFavorite(w, r, clubID)
+	if err == nil && !removed {
+		RenderError(w, r, http.StatusNotFound, "club is not a favorite", nil)
+		return
+	}
+	writeFavorites(w, r, http.StatusOK, favorites, err)
+}
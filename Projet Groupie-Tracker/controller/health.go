@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+// apiCheckTTL est la durée pendant laquelle le résultat du test de l'API
+// distante est réutilisé : football-data.org limite le nombre d'appels
+// par minute et les sondes interrogent `/healthz` très souvent.
+const apiCheckTTL = time.Minute
+
+// HealthConfig décrit les dépendances vérifiées par `/healthz`.
+type HealthConfig struct {
+	// DataFiles associe un nom ("clubs", "squads"...) au chemin du fichier.
+	DataFiles map[string]string
+	// PingAPI teste l'API distante ; nil si aucune API n'est configurée.
+	PingAPI func(ctx context.Context) error
+}
+
+var health = struct {
+	sync.Mutex
+	cfg       HealthConfig
+	started   time.Time
+	api       CheckResult
+	apiExpiry time.Time
+}{started: time.Now()}
+
+// SetHealth définit les dépendances vérifiées par `/healthz`.
+func SetHealth(cfg HealthConfig) {
+	health.Lock()
+	defer health.Unlock()
+	health.cfg = cfg
+	health.apiExpiry = time.Time{}
+}
+
+// CheckResult est le résultat d'une vérification : "ok", "error" ou
+// "disabled" (dépendance non configurée).
+type CheckResult struct {
+	Status    string     `json:"status"`
+	Path      string     `json:"path,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checkedAt,omitempty"`
+}
+
+type HealthResponse struct {
+	Status    string                 `json:"status"` // "ok" ou "degraded"
+	Uptime    string                 `json:"uptime"`
+	DataFiles map[string]CheckResult `json:"dataFiles"`
+	API       CheckResult            `json:"api"`
+}
+
+type ReadyResponse struct {
+	Status string `json:"status"` // "ready" ou "not ready"
+	Error  string `json:"error,omitempty"`
+}
+
+// checkDataFile vérifie que le fichier `path` existe et peut être ouvert
+// en lecture.
+func checkDataFile(path string) CheckResult {
+	found, err := models.FindDataFile(path)
+	if err != nil {
+		return CheckResult{Status: "error", Path: path, Error: err.Error()}
+	}
+	f, err := os.Open(found)
+	if err != nil {
+		return CheckResult{Status: "error", Path: found, Error: err.Error()}
+	}
+	f.Close()
+	return CheckResult{Status: "ok", Path: found}
+}
+
+// checkAPI teste l'API distante, au plus une fois par `apiCheckTTL`.
+func checkAPI(ctx context.Context) CheckResult {
+	health.Lock()
+	defer health.Unlock()
+	if health.cfg.PingAPI == nil {
+		return CheckResult{Status: "disabled"}
+	}
+	if time.Now().Before(health.apiExpiry) {
+		return health.api
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	now := time.Now().UTC()
+	result := CheckResult{Status: "ok", CheckedAt: &now}
+	if err := health.cfg.PingAPI(ctx); err != nil {
+		result.Status, result.Error = "error", err.Error()
+	}
+	health.api, health.apiExpiry = result, time.Now().Add(apiCheckTTL)
+	return result
+}
+
+// withoutDetails renvoie `c` sans chemin ni message d'erreur, qui
+// révéleraient l'arborescence du serveur ou les réponses de l'API.
+func (c CheckResult) withoutDetails() CheckResult {
+	c.Path, c.Error = "", ""
+	return c
+}
+
+// Healthz gère `/healthz` : le serveur répond toujours 200 tant qu'il
+// fonctionne, avec en JSON l'accessibilité des fichiers de données et
+// de l'API football-data.org. `status` vaut "degraded" si l'une des
+// vérifications échoue (l'application peut alors servir des données de
+// repli ou en cache). Les chemins des fichiers et les messages d'erreur
+// ne sont donnés qu'aux administrateurs.
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	health.Lock()
+	files, started := health.cfg.DataFiles, health.started
+	health.Unlock()
+
+	resp := HealthResponse{
+		Status:    "ok",
+		Uptime:    time.Since(started).Round(time.Second).String(),
+		DataFiles: map[string]CheckResult{},
+		API:       checkAPI(r.Context()),
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		resp.DataFiles[name] = checkDataFile(files[name])
+		if resp.DataFiles[name].Status != "ok" {
+			resp.Status = "degraded"
+		}
+	}
+	if resp.API.Status == "error" {
+		resp.Status = "degraded"
+	}
+	if !isAdmin(r) {
+		for name, c := range resp.DataFiles {
+			resp.DataFiles[name] = c.withoutDetails()
+		}
+		resp.API = resp.API.withoutDetails()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Readyz gère `/readyz` : 200 si le serveur peut servir des pages (les
+// clubs se chargent et les templates sont analysés), 503 sinon, pour que
+// le répartiteur de charge n'envoie pas de trafic à cette instance.
+// La cause est journalisée, et n'est renvoyée qu'aux administrateurs.
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	resp := ReadyResponse{Status: "ready"}
+	status := http.StatusOK
+	if err := ready(); err != nil {
+		log.Printf("not ready: %v", err)
+		resp.Status = "not ready"
+		if isAdmin(r) {
+			resp.Error = err.Error()
+		}
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ready vérifie que les clubs et le template de la page d'accueil sont
+// disponibles.
+func ready() error {
+	clubs, err := clubRepo.All()
+	if err != nil {
+		return err
+	}
+	if len(clubs) == 0 {
+		return errors.New("no clubs loaded")
+	}
+	_, err = lookupTemplate("index.html")
+	return err
+}
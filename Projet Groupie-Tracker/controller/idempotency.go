@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"groupie_tracker/middleware"
+)
+
+const (
+	// idempotencyTTL est la durée pendant laquelle une réponse reste
+	// rejouable pour une même clé `Idempotency-Key`.
+	idempotencyTTL = 24 * time.Hour
+	// idempotencySweep est l'intervalle de suppression des entrées expirées.
+	idempotencySweep = 10 * time.Minute
+	// maxIdempotentEntries borne le nombre de clés mémorisées : au-delà,
+	// les plus anciennes sont oubliées.
+	maxIdempotentEntries = 10000
+	// maxIdempotentBody limite la taille du corps mémorisé pour l'empreinte
+	// (un import de favoris, fichier et enveloppe multipart, doit passer).
+	maxIdempotentBody = 2 << 20
+	// maxIdempotentResponse limite la taille d'une réponse mémorisée : au-delà,
+	// elle n'est pas enregistrée.
+	maxIdempotentResponse = 256 << 10
+)
+
+// idempotentEntry mémorise l'empreinte d'une requête et la réponse
+// produite la première fois qu'elle a été traitée.
+type idempotentEntry struct {
+	key         string
+	fingerprint string
+	done        bool
+	status      int
+	header      http.Header
+	cookies     []string // en-têtes Set-Cookie écrits par le handler
+	body        []byte
+	expires     time.Time
+}
+
+// idempotency garde les entrées par clé, et leur ordre d'arrivée dans
+// `order` (la plus ancienne en tête) pour borner leur nombre. Les entrées
+// expirées sont supprimées par `sweepIdempotency`, lancée au premier usage.
+var idempotency = struct {
+	sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	sweeper sync.Once
+}{entries: make(map[string]*list.Element), order: list.New()}
+
+// sweepIdempotency supprime les entrées terminées et expirées toutes les
+// `idempotencySweep`.
+func sweepIdempotency() {
+	for range time.Tick(idempotencySweep) {
+		now := time.Now()
+		idempotency.Lock()
+		for el := idempotency.order.Front(); el != nil; {
+			next := el.Next()
+			if e := el.Value.(*idempotentEntry); e.done && now.After(e.expires) {
+				idempotency.order.Remove(el)
+				delete(idempotency.entries, e.key)
+			}
+			el = next
+		}
+		idempotency.Unlock()
+	}
+}
+
+// removeIdempotent oublie `entry` si elle est toujours celle de sa clé.
+// L'appelant tient le verrou.
+func removeIdempotent(entry *idempotentEntry) {
+	if el, ok := idempotency.entries[entry.key]; ok && el.Value == entry {
+		idempotency.order.Remove(el)
+		delete(idempotency.entries, entry.key)
+	}
+}
+
+// idempotencyScope renvoie l'espace de noms des clés de la requête : le
+// compte connecté, sinon le navigateur (cookie CSRF), sinon l'adresse du
+// client. Deux visiteurs utilisant la même clé ne partagent pas de réponse.
+func idempotencyScope(r *http.Request) string {
+	if u := currentUser(r); u != nil {
+		return "user:" + strconv.FormatInt(u.ID, 10)
+	}
+	if c, err := r.Cookie(middleware.CSRFCookie); err == nil && c.Value != "" {
+		return "browser:" + c.Value
+	}
+	return "ip:" + clientIP(r)
+}
+
+// responseRecorder transmet la réponse au client tout en conservant
+// une copie du statut et du corps pour pouvoir la rejouer. Au-delà de
+// `maxIdempotentResponse` octets, la copie est abandonnée (`tooLarge`).
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	tooLarge bool
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if !rec.tooLarge {
+		if rec.body.Len()+len(b) > maxIdempotentResponse {
+			rec.tooLarge = true
+			rec.body = bytes.Buffer{}
+		} else {
+			rec.body.Write(b)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Idempotent enveloppe un handler POST pour prendre en charge l'en-tête
+// `Idempotency-Key`. Comportement:
+//   - Sans clé (ou pour une autre méthode que POST), le handler est appelé normalement.
+//   - Les clés sont propres au compte ou au navigateur (voir `idempotencyScope`).
+//   - À la première requête portant une clé, la réponse (statut, en-têtes,
+//     corps) est enregistrée avec l'empreinte de la requête (méthode,
+//     chemin, paramètres et corps). Seuls les cookies posés par le handler
+//     sont rejoués (le cookie `favorites` d'un visiteur anonyme), pas ceux
+//     des middlewares, qui les reposent eux-mêmes. Une erreur 5xx, une
+//     réponse de plus de `maxIdempotentResponse` octets ou un handler
+//     interrompu par un panic n'est pas enregistré : la requête pourra être
+//     retentée avec la même clé.
+//   - Une nouvelle tentative avec la même clé et la même requête rejoue la
+//     réponse d'origine sans ré-exécuter le handler (en-tête `Idempotent-Replayed`).
+//   - Réutiliser la clé pour une requête différente renvoie 422, et une
+//     tentative arrivant pendant le premier traitement renvoie 409.
+//   - Un corps de plus de `maxIdempotentBody` octets renvoie 413.
+func Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != http.MethodPost || key == "" {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+		if err != nil {
+			RenderError(w, r, http.StatusBadRequest, "cannot read request body", err)
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			RenderError(w, r, http.StatusRequestEntityTooLarge, "", nil)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.RequestURI()+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+		key = idempotencyScope(r) + "\x00" + key
+
+		idempotency.sweeper.Do(func() { go sweepIdempotency() })
+		idempotency.Lock()
+		if el, ok := idempotency.entries[key]; ok {
+			entry := el.Value.(*idempotentEntry)
+			fp, done, status, header, cookies, stored := entry.fingerprint, entry.done, entry.status, entry.header, entry.cookies, entry.body
+			idempotency.Unlock()
+			switch {
+			case fp != fingerprint:
+				RenderError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key already used for a different request", nil)
+			case !done:
+				RenderError(w, r, http.StatusConflict, "a request with this Idempotency-Key is already in progress", nil)
+			default:
+				for k, v := range header {
+					w.Header()[k] = v
+				}
+				for _, c := range cookies {
+					w.Header().Add("Set-Cookie", c)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(status)
+				w.Write(stored)
+			}
+			return
+		}
+		for idempotency.order.Len() >= maxIdempotentEntries {
+			removeIdempotent(idempotency.order.Front().Value.(*idempotentEntry))
+		}
+		entry := &idempotentEntry{key: key, fingerprint: fingerprint}
+		idempotency.entries[key] = idempotency.order.PushBack(entry)
+		idempotency.Unlock()
+
+		finished := false
+		defer func() {
+			if !finished {
+				// Panic du handler : la clé est libérée pour une nouvelle tentative.
+				idempotency.Lock()
+				removeIdempotent(entry)
+				idempotency.Unlock()
+			}
+		}()
+		// Cookies déjà posés par les middlewares (CSRF...), à ne pas enregistrer.
+		before := len(w.Header().Values("Set-Cookie"))
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		finished = true
+
+		idempotency.Lock()
+		defer idempotency.Unlock()
+		if rec.status >= http.StatusInternalServerError || rec.tooLarge {
+			removeIdempotent(entry)
+			return
+		}
+		entry.done = true
+		entry.status = rec.status
+		entry.header = w.Header().Clone()
+		if cookies := entry.header.Values("Set-Cookie"); len(cookies) > before {
+			entry.cookies = cookies[before:]
+		}
+		entry.header.Del("Set-Cookie")
+		entry.body = rec.body.Bytes()
+		entry.expires = time.Now().Add(idempotencyTTL)
+	}
+}
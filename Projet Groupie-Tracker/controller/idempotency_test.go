@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// idempotentRequest construit un POST vers `target` portant la clé `key`,
+// depuis le navigateur `browser` (cookie CSRF).
+func idempotentRequest(target, key, browser, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set("Idempotency-Key", key)
+	}
+	r.AddCookie(&http.Cookie{Name: "csrf_token", Value: browser})
+	return r
+}
+
+func TestIdempotent(t *testing.T) {
+	type step struct {
+		target, key, browser, body string
+		wantStatus                 int
+		wantReplayed               bool
+	}
+	tests := []struct {
+		name      string
+		status    int
+		size      int // octets ajoutés à la réponse
+		steps     []step
+		wantCalls int
+	}{
+		{
+			name:   "no key runs every time",
+			status: http.StatusCreated,
+			steps: []step{
+				{"/api/t", "", "b1", "a", http.StatusCreated, false},
+				{"/api/t", "", "b1", "a", http.StatusCreated, false},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "same request is replayed",
+			status: http.StatusCreated,
+			steps: []step{
+				{"/api/t", "k", "b1", "a", http.StatusCreated, false},
+				{"/api/t", "k", "b1", "a", http.StatusCreated, true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "different body is rejected",
+			status: http.StatusCreated,
+			steps: []step{
+				{"/api/t", "k", "b1", "a", http.StatusCreated, false},
+				{"/api/t", "k", "b1", "b", http.StatusUnprocessableEntity, false},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "different query is rejected",
+			status: http.StatusCreated,
+			steps: []step{
+				{"/api/t?id=1", "k", "b1", "a", http.StatusCreated, false},
+				{"/api/t?id=2", "k", "b1", "a", http.StatusUnprocessableEntity, false},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "keys are scoped per browser",
+			status: http.StatusCreated,
+			steps: []step{
+				{"/api/t", "k", "b1", "a", http.StatusCreated, false},
+				{"/api/t", "k", "b2", "a", http.StatusCreated, false},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "server errors are not stored",
+			status: http.StatusInternalServerError,
+			steps: []step{
+				{"/api/t", "k", "b1", "a", http.StatusInternalServerError, false},
+				{"/api/t", "k", "b1", "a", http.StatusInternalServerError, false},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "large response is not stored",
+			status: http.StatusOK,
+			size:   maxIdempotentResponse,
+			steps: []step{
+				{"/api/t", "k", "b1", "a", http.StatusOK, false},
+				{"/api/t", "k", "b1", "a", http.StatusOK, false},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "oversized body is rejected",
+			status: http.StatusCreated,
+			steps: []step{
+				{"/api/t", "k", "b1", strings.Repeat("x", maxIdempotentBody+1), http.StatusRequestEntityTooLarge, false},
+			},
+			wantCalls: 0,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := Idempotent(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				http.SetCookie(w, &http.Cookie{Name: "c", Value: "v"})
+				w.WriteHeader(tt.status)
+				fmt.Fprintf(w, "call %d", calls)
+				w.Write(make([]byte, tt.size))
+			})
+			var first string
+			for j, s := range tt.steps {
+				key := s.key
+				if key != "" {
+					// Les entrées sont globales : une clé par cas de test.
+					key = fmt.Sprintf("%s-%d", key, i)
+				}
+				w := httptest.NewRecorder()
+				// Cookie posé par un middleware avant le handler.
+				w.Header().Add("Set-Cookie", "mw=1")
+				h(w, idempotentRequest(s.target, key, s.browser, s.body))
+				if w.Code != s.wantStatus {
+					t.Fatalf("step %d: status %d, want %d", j, w.Code, s.wantStatus)
+				}
+				replayed := w.Header().Get("Idempotent-Replayed") == "true"
+				if replayed != s.wantReplayed {
+					t.Errorf("step %d: replayed = %v, want %v", j, replayed, s.wantReplayed)
+				}
+				if replayed {
+					if w.Body.String() != first {
+						t.Errorf("step %d: replayed body %q, want %q", j, w.Body.String(), first)
+					}
+					if got := w.Header().Values("Set-Cookie"); !slices.Equal(got, []string{"mw=1", "c=v"}) {
+						t.Errorf("step %d: Set-Cookie = %q, want the middleware's and the handler's cookies once", j, got)
+					}
+				}
+				if j == 0 {
+					first = w.Body.String()
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotentPanicReleasesKey(t *testing.T) {
+	calls := 0
+	h := Idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	func() {
+		defer func() { recover() }()
+		h(httptest.NewRecorder(), idempotentRequest("/api/t", "panic", "b1", "a"))
+	}()
+	w := httptest.NewRecorder()
+	h(w, idempotentRequest("/api/t", "panic", "b1", "a"))
+	if w.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("retry after panic: status %d, calls %d; want 201 and 2 calls", w.Code, calls)
+	}
+}
@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"groupie_tracker/live"
+	"groupie_tracker/models"
+)
+
+const (
+	// maxLiveClubs limite le nombre de clubs suivis par un flux.
+	maxLiveClubs = 50
+	// liveHeartbeat est l'intervalle des commentaires envoyés sur un flux
+	// inactif, pour que les proxys ne coupent pas la connexion.
+	liveHeartbeat = 25 * time.Second
+)
+
+// liveHub diffuse les changements de matchs ; nil tant que `SetLiveHub`
+// n'a pas été appelée (`/events` répond alors 503).
+var liveHub *live.Hub
+
+// livePoller relit les matchs pour le hub et fournit la dernière liste lue
+// à `/api/live` ; nil tant que `SetLivePoller` n'a pas été appelée.
+var livePoller *live.Poller
+
+// SetLiveHub définit le hub des mises à jour en direct.
+func SetLiveHub(h *live.Hub) {
+	liveHub = h
+}
+
+// SetLivePoller définit le poller dont `/api/live` sert les matchs.
+func SetLivePoller(p *live.Poller) {
+	livePoller = p
+}
+
+// LiveResponse est la réponse de `/api/live`.
+type LiveResponse struct {
+	Matches []MatchView `json:"matches"`
+}
+
+// LiveEvent est un changement de match envoyé sur `/events` : le match
+// avec le nom des équipes, et son statut et son score précédents.
+type LiveEvent struct {
+	MatchView
+	PreviousStatus    string `json:"previousStatus"`
+	PreviousHomeScore *int   `json:"previousHomeScore,omitempty"`
+	PreviousAwayScore *int   `json:"previousAwayScore,omitempty"`
+}
+
+// liveClubIDs lit la liste `clubs` (ex: "1,2,3") de la requête ou, à
+// défaut, les favoris du visiteur. Un ID invalide ou plus de
+// `maxLiveClubs` clubs renvoient une erreur ; les doublons sont ignorés.
+func liveClubIDs(r *http.Request) ([]int, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("clubs"))
+	values := strings.Split(raw, ",")
+	if raw == "" {
+		values = getFavorites(r)
+	}
+	seen := map[int]bool{}
+	var ids []int
+	for _, v := range values {
+		id, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid club id %q", v)
+		}
+		if seen[id] {
+			continue
+		}
+		if len(ids) == maxLiveClubs {
+			return nil, fmt.Errorf("at most %d clubs can be followed", maxLiveClubs)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Events gère `/events`, un flux Server-Sent Events des changements de
+// score et de statut des matchs des clubs `clubs` (par défaut les favoris
+// du visiteur). Chaque changement est un événement `match` dont la donnée
+// est un `LiveEvent` en JSON. Sans club à suivre, la réponse est 204 : le
+// navigateur ne se reconnecte pas. Le flux se termine quand le client se
+// déconnecte ou quand le serveur s'arrête (le navigateur se reconnecte
+// alors après le délai `retry`).
+func Events(w http.ResponseWriter, r *http.Request) {
+	if liveHub == nil {
+		RenderError(w, r, http.StatusServiceUnavailable, "live updates are disabled", nil)
+		return
+	}
+	ids, err := liveClubIDs(r)
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if len(ids) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Le flux dure plus longtemps que le WriteTimeout du serveur.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("events: cannot clear write deadline: %v", err)
+	}
+	sub := liveHub.Subscribe(ids)
+	defer liveHub.Unsubscribe(sub)
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 10000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	clubs := loadClubs()
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			b, err := json.Marshal(LiveEvent{
+				MatchView:         matchViews([]models.Match{ev.Match}, clubs)[0],
+				PreviousStatus:    ev.Previous.Status,
+				PreviousHomeScore: ev.Previous.HomeScore,
+				PreviousAwayScore: ev.Previous.AwayScore,
+			})
+			if err != nil {
+				log.Printf("events: %v", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: match\ndata: %s\n\n", b)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// LiveAPI gère `/api/live` : les matchs en cours (IN_PLAY ou PAUSED) de
+// la dernière liste lue par le poller, avec le nom des équipes. Les
+// requêtes ne déclenchent pas d'appel à l'API amont, au plus une lecture
+// par intervalle du poller (voir `live.Poller.Latest`).
+func LiveAPI(w http.ResponseWriter, r *http.Request) {
+	if livePoller == nil {
+		RenderError(w, r, http.StatusServiceUnavailable, "live updates are disabled", nil)
+		return
+	}
+	matches, err := livePoller.Latest(r.Context())
+	if err != nil {
+		RenderError(w, r, http.StatusServiceUnavailable, "live matches are unavailable", err)
+		return
+	}
+	inPlay := []models.Match{}
+	for _, m := range matches {
+		if m.Live() {
+			inPlay = append(inPlay, m)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(LiveResponse{Matches: matchViews(inPlay, loadClubs())})
+}
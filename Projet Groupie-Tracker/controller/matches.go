@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+// MatchView est un match accompagné des noms des deux équipes, pour les
+// templates comme pour l'API.
+type MatchView struct {
+	models.Match
+	HomeTeam string `json:"homeTeam"`
+	AwayTeam string `json:"awayTeam"`
+}
+
+type MatchesResponse struct {
+	Matches    []MatchView `json:"matches"`
+	Total      int         `json:"total"`
+	Page       int         `json:"page"`
+	PageSize   int         `json:"pageSize"`
+	TotalPages int         `json:"totalPages"`
+}
+
+// matchStore fournit les matchs aux handlers.
+// Il est remplacé au démarrage par `SetMatchStore` (voir `router.New`).
+var matchStore = models.NewMatchStore("data/matches.json", models.DefaultClubTTL)
+
+// SetMatchStore définit le store de matchs utilisé par les handlers.
+func SetMatchStore(s *models.MatchStore) {
+	matchStore = s
+}
+
+// loadMatches renvoie tous les matchs triés par date.
+// En cas d'échec, l'erreur est loggée et une slice vide est renvoyée.
+func loadMatches() []models.Match {
+	matches, err := matchStore.All()
+	if err != nil {
+		log.Printf("failed to load matches: %v", err)
+		return []models.Match{}
+	}
+	return matches
+}
+
+// filterMatches applique les filtres de requête communs à `/matches` et
+// `/api/matches`:
+//   - `clubId` : matchs joués par ce club (domicile ou extérieur) ;
+//   - `dateFrom`, `dateTo` : bornes incluses au format AAAA-MM-JJ ;
+//   - `status` : SCHEDULED (matchs à venir, y compris TIMED), FINISHED,
+//     ou tout autre statut football-data.org comparé tel quel ;
+//   - `only=favorites` : matchs impliquant un club de `favorites` (les
+//     favoris du visiteur, voir `favoriteIDSet`).
+//
+// Les valeurs invalides sont ignorées, comme pour les filtres d'année des clubs.
+func filterMatches(matches []models.Match, q url.Values, favorites map[string]bool) []models.Match {
+	clubID, clubErr := strconv.Atoi(q.Get("clubId"))
+	onlyFavorites := q.Get("only") == "favorites"
+	status := strings.ToUpper(q.Get("status"))
+	from, fromErr := time.Parse("2006-01-02", q.Get("dateFrom"))
+	to, toErr := time.Parse("2006-01-02", q.Get("dateTo"))
+
+	filtered := []models.Match{}
+	for _, m := range matches {
+		if clubErr == nil && !m.Involves(clubID) {
+			continue
+		}
+		if onlyFavorites && !favorites[strconv.Itoa(m.HomeTeamID)] && !favorites[strconv.Itoa(m.AwayTeamID)] {
+			continue
+		}
+		if fromErr == nil && m.UtcDate.Before(from) {
+			continue
+		}
+		// dateTo inclut toute la journée
+		if toErr == nil && !m.UtcDate.Before(to.AddDate(0, 0, 1)) {
+			continue
+		}
+		switch {
+		case status == "":
+		case status == models.StatusScheduled:
+			if !m.Upcoming() {
+				continue
+			}
+		case m.Status != status:
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+	return filtered
+}
+
+// matchViews associe à chaque match le nom de ses équipes.
+func matchViews(matches []models.Match, clubs []models.Club) []MatchView {
+	names := make(map[int]string, len(clubs))
+	for _, c := range clubs {
+		names[c.ID] = c.Name
+	}
+	views := make([]MatchView, 0, len(matches))
+	for _, m := range matches {
+		views = append(views, MatchView{
+			Match:    m,
+			HomeTeam: names[m.HomeTeamID],
+			AwayTeam: names[m.AwayTeamID],
+		})
+	}
+	return views
+}
+
+// Matches gère la page `/matches` : liste des rencontres filtrées par
+// club, période, statut et favoris (voir `filterMatches`), rendue avec `matches.html`.
+// Lorsqu'un `clubId` valide est fourni, le titre reprend le nom du club.
+func Matches(w http.ResponseWriter, r *http.Request) {
+	clubs := loadClubs()
+	q := r.URL.Query()
+
+	data := PageData{
+		Title:   tr(r, "matches.title"),
+		Message: tr(r, "matches.message"),
+		Clubs:   clubs,
+		Matches: matchViews(filterMatches(loadMatches(), q, favoriteIDSet(r)), clubs),
+		Filters: q,
+		Meta:    pageMeta(r, "meta.matches"),
+	}
+	if id, err := strconv.Atoi(q.Get("clubId")); err == nil {
+		if club, err := models.GetClubByID(clubs, id); err == nil {
+			data.Title = tr(r, "matches.club_title", club.Name)
+			data.Club = club
+		}
+	}
+	renderTemplate(w, r, "matches.html", data)
+}
+
+// MatchesAPI fournit l'endpoint `/api/matches` en JSON avec les mêmes
+// filtres que la page `/matches` et une pagination `page`/`pageSize`
+// identique à celle de `/api/clubs`.
+func MatchesAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	filtered := filterMatches(loadMatches(), q, favoriteIDSet(r))
+
+	page := 1
+	pageSize := 20
+	if p, err := strconv.Atoi(q.Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if ps, err := strconv.Atoi(q.Get("pageSize")); err == nil && ps > 0 && ps <= 100 {
+		pageSize = ps
+	}
+
+	total := len(filtered)
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	json.NewEncoder(w).Encode(MatchesResponse{
+		Matches:    matchViews(filtered[start:end], loadClubs()),
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	})
+}
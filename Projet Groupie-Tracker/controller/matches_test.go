@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+func TestFilterMatches(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 8, d, 15, 0, 0, 0, time.UTC) }
+	matches := []models.Match{
+		{ID: 1, UtcDate: day(1), Status: models.StatusFinished, HomeTeamID: 1, AwayTeamID: 2},
+		{ID: 2, UtcDate: day(2), Status: models.StatusTimed, HomeTeamID: 3, AwayTeamID: 1},
+		{ID: 3, UtcDate: day(3), Status: models.StatusScheduled, HomeTeamID: 2, AwayTeamID: 3},
+		{ID: 4, UtcDate: day(4), Status: models.StatusInPlay, HomeTeamID: 4, AwayTeamID: 5},
+	}
+	favorites := map[string]bool{"1": true, "5": true}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"no filter", "", []int{1, 2, 3, 4}},
+		{"club", "clubId=3", []int{2, 3}},
+		{"scheduled includes timed", "status=scheduled", []int{2, 3}},
+		{"other status", "status=IN_PLAY", []int{4}},
+		{"dates are inclusive", "dateFrom=2025-08-02&dateTo=2025-08-03", []int{2, 3}},
+		{"invalid values are ignored", "clubId=x&dateFrom=tomorrow", []int{1, 2, 3, 4}},
+		{"favorites", "only=favorites", []int{1, 2, 4}},
+		{"favorites and club", "only=favorites&clubId=2", []int{1}},
+		{"unknown only value", "only=everything", []int{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			got := []int{}
+			for _, m := range filterMatches(matches, q, favorites) {
+				got = append(got, m.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterMatches(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	q, _ := url.ParseQuery("only=favorites")
+	if got := filterMatches(matches, q, nil); len(got) != 0 {
+		t.Errorf("only=favorites without favorites = %d matches, want none", len(got))
+	}
+}
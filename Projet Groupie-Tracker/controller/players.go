@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"groupie_tracker/models"
+)
+
+type PlayersResponse struct {
+	Players    []models.Player `json:"players"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"pageSize"`
+	TotalPages int             `json:"totalPages"`
+}
+
+// playerStore fournit les effectifs aux handlers.
+// Il est remplacé au démarrage par `SetPlayerStore` (voir `router.New`).
+var playerStore = models.NewPlayerStore("data/squads.json", models.DefaultClubTTL)
+
+// SetPlayerStore définit le store de joueurs utilisé par les handlers.
+func SetPlayerStore(s *models.PlayerStore) {
+	playerStore = s
+}
+
+// loadSquad renvoie l'effectif du club `clubID`.
+// En cas d'échec, l'erreur est loggée et une slice vide est renvoyée.
+func loadSquad(clubID int) []models.Player {
+	squad, err := playerStore.ByClub(clubID)
+	if err != nil {
+		log.Printf("failed to load players: %v", err)
+		return []models.Player{}
+	}
+	return squad
+}
+
+// loadPlayers renvoie les joueurs de tous les effectifs.
+// En cas d'échec, l'erreur est loggée et une slice vide est renvoyée.
+func loadPlayers() []models.Player {
+	players, err := playerStore.All()
+	if err != nil {
+		log.Printf("failed to load players: %v", err)
+		return []models.Player{}
+	}
+	return players
+}
+
+// ClubPlayers gère la route `/club/{id}/players` et affiche l'effectif
+// complet d'un club dans le template `squad.html`.
+// Un ID invalide ou inconnu renvoie la page 404, une erreur de chargement 500.
+func ClubPlayers(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		NotFound(w, r)
+		return
+	}
+	club, err := clubRepo.ByID(id)
+	if errors.Is(err, models.ErrClubNotFound) {
+		NotFound(w, r)
+		return
+	}
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to load club %d: %w", id, err))
+		return
+	}
+
+	data := PageData{
+		Title:   tr(r, "squad.title", club.Name),
+		Message: club.Name,
+		Club:    club,
+		Players: loadSquad(club.ID),
+		Meta:    clubMeta(r, club),
+	}
+	data.Meta.Description = tr(r, "meta.squad", club.Name)
+	data.Meta.Canonical += "/players"
+	renderTemplate(w, r, "squad.html", data)
+}
+
+// PlayersAPI fournit l'endpoint `/api/players` en JSON.
+// Paramètres de requête:
+//   - `search` : sous-chaîne du nom du joueur (insensible à la casse) ;
+//   - `position` : poste exact (Goalkeeper, Defence, Midfield, Offence) ;
+//   - `nationality` : nationalité exacte (insensible à la casse) ;
+//   - `clubId` : restreint à l'effectif d'un club ;
+//   - `page`, `pageSize` : pagination, comme pour `/api/clubs`.
+func PlayersAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	players := loadPlayers()
+
+	q := r.URL.Query()
+	search := strings.ToLower(q.Get("search"))
+	position := q.Get("position")
+	nationality := q.Get("nationality")
+	clubID, clubErr := strconv.Atoi(q.Get("clubId"))
+
+	page := 1
+	pageSize := 20
+	if p, err := strconv.Atoi(q.Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if ps, err := strconv.Atoi(q.Get("pageSize")); err == nil && ps > 0 && ps <= 100 {
+		pageSize = ps
+	}
+
+	filtered := []models.Player{}
+	for _, p := range players {
+		if search != "" && !strings.Contains(strings.ToLower(p.Name), search) {
+			continue
+		}
+		if position != "" && !strings.EqualFold(p.Position, position) {
+			continue
+		}
+		if nationality != "" && !strings.EqualFold(p.Nationality, nationality) {
+			continue
+		}
+		if clubErr == nil && p.ClubID != clubID {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+
+	total := len(filtered)
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+
+	json.NewEncoder(w).Encode(PlayersResponse{
+		Players:    filtered[start:end],
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	})
+}
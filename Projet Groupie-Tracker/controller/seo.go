@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"groupie_tracker/i18n"
+	"groupie_tracker/models"
+)
+
+// Meta décrit les métadonnées d'une page pour les moteurs de recherche et
+// les aperçus des réseaux sociaux (OpenGraph), écrites dans le `<head>`
+// par la fonction de template `metaTags`. Les champs vides prennent une
+// valeur par défaut (description générale, URL de la page sans ses
+// paramètres).
+type Meta struct {
+	Description string
+	Canonical   string // URL canonique, absolue ou relative au site
+	Image       string // image de l'aperçu (blason du club), idem
+	NoIndex     bool   // page à ne pas indexer (compte, favoris, erreurs...)
+}
+
+// SEOConfig décrit le site pour `/sitemap.xml`, `/robots.txt` et les URL
+// canoniques.
+type SEOConfig struct {
+	// BaseURL est l'URL publique du site (ex: "https://foot.example.org").
+	// Vide, elle est déduite de chaque requête (hôte et protocole).
+	BaseURL string
+	// ClubsFile, SquadsFile et MatchesFile sont les fichiers de données
+	// dont la date de modification sert de `lastmod` au sitemap.
+	ClubsFile   string
+	SquadsFile  string
+	MatchesFile string
+}
+
+var seo = struct {
+	sync.Mutex
+	cfg SEOConfig
+}{}
+
+// SetSEO définit la configuration du sitemap et des URL canoniques.
+func SetSEO(cfg SEOConfig) {
+	seo.Lock()
+	defer seo.Unlock()
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	seo.cfg = cfg
+}
+
+func seoConfig() SEOConfig {
+	seo.Lock()
+	defer seo.Unlock()
+	return seo.cfg
+}
+
+// siteURL renvoie l'URL publique du site, sans "/" final.
+func siteURL(r *http.Request) string {
+	if base := seoConfig().BaseURL; base != "" {
+		return base
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// absoluteURL rend `path` absolue si elle est relative au site.
+func absoluteURL(r *http.Request, path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return siteURL(r) + path
+}
+
+// pageMeta renvoie les métadonnées d'une page décrite par la clé de
+// traduction `key`.
+func pageMeta(r *http.Request, key string, args ...interface{}) Meta {
+	return Meta{Description: tr(r, key, args...)}
+}
+
+// clubMeta renvoie les métadonnées de la fiche de `club` : description
+// construite à partir du club et blason en image d'aperçu.
+func clubMeta(r *http.Request, club models.Club) Meta {
+	desc := club.Name
+	if club.TLA != "" {
+		desc += " (" + club.TLA + ")"
+	}
+	desc += " — " + tr(r, "meta.club", club.Founded, club.Venue)
+	m := Meta{Description: desc, Canonical: fmt.Sprintf("/club/%d", club.ID)}
+	if club.CrestURL != "" {
+		m.Image = crestURL(club, 256)
+	}
+	return m
+}
+
+// metaTags écrit les balises `<meta>` de `data` : description, URL
+// canonique, OpenGraph et carte Twitter.
+func metaTags(r *http.Request, data PageData) template.HTML {
+	m := data.Meta
+	if m.Description == "" {
+		m.Description = tr(r, "meta.default")
+	}
+	if m.Canonical == "" {
+		m.Canonical = r.URL.Path
+	}
+	canonical := absoluteURL(r, m.Canonical)
+	lang := i18n.Lang(r)
+
+	var tags []string
+	tag := func(attr, name, content string) {
+		tags = append(tags, fmt.Sprintf(`<meta %s="%s" content="%s">`, attr, name, template.HTMLEscapeString(content)))
+	}
+	tag("name", "description", m.Description)
+	if m.NoIndex {
+		tag("name", "robots", "noindex")
+	} else {
+		tags = append(tags, `<link rel="canonical" href="`+template.HTMLEscapeString(canonical)+`">`)
+	}
+	tag("property", "og:type", "website")
+	tag("property", "og:site_name", tr(r, "nav.brand"))
+	tag("property", "og:title", data.Title)
+	tag("property", "og:description", m.Description)
+	tag("property", "og:url", canonical)
+	tag("property", "og:locale", lang)
+	if m.Image != "" {
+		tag("property", "og:image", absoluteURL(r, m.Image))
+		tag("name", "twitter:card", "summary")
+	}
+	// Une balise par ligne, alignées sur l'indentation du <head>.
+	return template.HTML(strings.Join(tags, "\n    "))
+}
+
+// sitemapURL est une entrée de `/sitemap.xml`.
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemap struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// lastMod renvoie la date de modification (AAAA-MM-JJ) du plus récent
+// des fichiers `paths`, ou "" si aucun n'est lisible.
+func lastMod(paths ...string) string {
+	var latest time.Time
+	for _, p := range paths {
+		found, err := models.FindDataFile(p)
+		if err != nil {
+			continue
+		}
+		if fi, err := os.Stat(found); err == nil && fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	if latest.IsZero() {
+		return ""
+	}
+	return latest.UTC().Format(time.DateOnly)
+}
+
+// Sitemap gère `/sitemap.xml` : les pages publiques et, pour chaque club,
+// sa fiche et son effectif. `lastmod` est la date de modification du
+// fichier de données dont la page dépend.
+func Sitemap(w http.ResponseWriter, r *http.Request) {
+	cfg := seoConfig()
+	clubs, err := clubRepo.All()
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("failed to load clubs: %w", err))
+		return
+	}
+	base := siteURL(r)
+	clubsMod := lastMod(cfg.ClubsFile)
+	matchesMod := lastMod(cfg.MatchesFile)
+	squadsMod := lastMod(cfg.SquadsFile)
+	clubMod := lastMod(cfg.ClubsFile, cfg.SquadsFile)
+
+	sm := sitemap{URLs: []sitemapURL{
+		{Loc: base + "/", LastMod: clubsMod},
+		{Loc: base + "/matches", LastMod: matchesMod},
+		{Loc: base + "/standings", LastMod: matchesMod},
+		{Loc: base + "/search"},
+		{Loc: base + "/about"},
+		{Loc: base + "/contact"},
+		{Loc: base + "/api-explorer"},
+	}}
+	for _, c := range clubs {
+		sm.URLs = append(sm.URLs,
+			sitemapURL{Loc: fmt.Sprintf("%s/club/%d", base, c.ID), LastMod: clubMod},
+			sitemapURL{Loc: fmt.Sprintf("%s/club/%d/players", base, c.ID), LastMod: squadsMod},
+		)
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(sm); err != nil {
+		log.Printf("sitemap: %v", err)
+	}
+}
+
+// robotsDisallow liste les chemins que les robots ne doivent pas visiter :
+// pages personnelles, administration, API et points de supervision.
+var robotsDisallow = []string{
+	"/admin", "/api/", "/api-explorer/try", "/favorites", "/compare",
+	"/login", "/register", "/logout", "/events",
+	"/healthz", "/readyz", "/metrics",
+}
+
+// Robots gère `/robots.txt` et indique l'emplacement du sitemap.
+func Robots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	for _, p := range robotsDisallow {
+		b.WriteString("Disallow: " + p + "\n")
+	}
+	b.WriteString("\nSitemap: " + siteURL(r) + "/sitemap.xml\n")
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,295 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"groupie_tracker/models"
+)
+
+// maxImportSize limite la taille d'un fichier de favoris importé.
+const maxImportSize = 1 << 20
+
+// errInvalidShareToken est renvoyée pour un jeton de partage mal formé.
+var errInvalidShareToken = errors.New("invalid share token")
+
+// ShareResponse est la réponse JSON de `/favorites/share`.
+type ShareResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+	Total int    `json:"total"`
+}
+
+// encodeShareToken encode une liste d'IDs de clubs en un jeton court,
+// utilisable dans une URL : les IDs sont écrits en varint puis encodés
+// en base64 URL sans remplissage. Le jeton ne dépend d'aucun état serveur,
+// il reste donc valable après un redémarrage.
+func encodeShareToken(ids []string) string {
+	buf := make([]byte, 0, len(ids)*2)
+	for _, id := range ids {
+		n, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		buf = binary.AppendUvarint(buf, n)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// decodeShareToken décode un jeton produit par encodeShareToken.
+func decodeShareToken(token string) ([]string, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) == 0 {
+		return nil, errInvalidShareToken
+	}
+	ids := []string{}
+	for len(buf) > 0 {
+		n, size := binary.Uvarint(buf)
+		if size <= 0 {
+			return nil, errInvalidShareToken
+		}
+		ids = append(ids, strconv.FormatUint(n, 10))
+		buf = buf[size:]
+	}
+	return ids, nil
+}
+
+// shareURL construit l'URL absolue d'import d'un jeton de partage, à
+// partir de l'URL publique du site (voir `siteURL`).
+func shareURL(r *http.Request, token string) string {
+	return siteURL(r) + "/favorites/import?token=" + token
+}
+
+// favoriteClubs renvoie les clubs correspondant aux IDs `ids`, dans l'ordre
+// du catalogue. Les IDs inconnus sont ignorés.
+func favoriteClubs(ids []string) []models.Club {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	clubs := []models.Club{}
+	for _, club := range loadClubs() {
+		if set[strconv.Itoa(club.ID)] {
+			clubs = append(clubs, club)
+		}
+	}
+	return clubs
+}
+
+// FavoritesExport gère `/favorites/export` et télécharge les clubs favoris
+// du visiteur. Le paramètre `format` choisit `json` (par défaut, tableau de
+// clubs) ou `csv` (une ligne d'en-tête puis un club par ligne). L'en-tête
+// `Content-Disposition` propose le nom `favoris.json` ou `favoris.csv`.
+// Les deux formats peuvent être ré-importés via `/favorites/import`.
+func FavoritesExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		methodNotAllowed(w, r, http.MethodGet, http.MethodHead)
+		return
+	}
+
+	clubs := favoriteClubs(getFavorites(r))
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="favoris.json"`)
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(clubs); err != nil {
+			log.Printf("favorites export failed: %v", err)
+		}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="favoris.csv"`)
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "name", "shortName", "tla", "founded", "venue", "website"})
+		for _, c := range clubs {
+			founded := ""
+			if c.Founded != 0 {
+				founded = strconv.Itoa(c.Founded)
+			}
+			cw.Write([]string{strconv.Itoa(c.ID), c.Name, c.ShortName, c.TLA, founded, c.Venue, c.Website})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			log.Printf("favorites export failed: %v", err)
+		}
+	default:
+		RenderError(w, r, http.StatusBadRequest, fmt.Sprintf("unknown format %q (expected json or csv)", format), nil)
+	}
+}
+
+// FavoritesShare gère `/favorites/share` et renvoie en JSON un lien court
+// permettant à un autre visiteur d'importer les favoris actuels. Le lien
+// pointe vers `/favorites/import?token=...`.
+func FavoritesShare(w http.ResponseWriter, r *http.Request) {
+	favorites := getFavorites(r)
+	if len(favorites) == 0 {
+		RenderError(w, r, http.StatusNotFound, "no favorites to share", nil)
+		return
+	}
+	token := encodeShareToken(favorites)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ShareResponse{Token: token, URL: shareURL(r, token), Total: len(favorites)})
+}
+
+// FavoritesImport gère `/favorites/import`.
+//   - GET sans paramètre affiche le formulaire d'envoi d'un fichier.
+//   - GET avec `token` affiche les clubs d'un lien de partage et un bouton
+//     pour les ajouter (la modification se fait toujours en POST).
+//   - POST fusionne dans les favoris les IDs du fichier `file` (export JSON
+//     ou CSV) ou du jeton `token`. Seuls les clubs existants sont ajoutés.
+//
+// La réponse est du JSON si la requête le demande (voir `wantsJSON`),
+// sinon une redirection vers `/favorites`.
+func FavoritesImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		data := PageData{
+			Title: tr(r, "import.title"),
+			User:  currentUser(r),
+			Meta:  Meta{NoIndex: true},
+		}
+		if token := r.URL.Query().Get("token"); token != "" {
+			ids, err := decodeShareToken(token)
+			if err != nil {
+				data.Message = tr(r, "import.invalid_token")
+			} else {
+				data.Clubs = favoriteClubs(ids)
+				data.ShareToken = token
+			}
+		}
+		renderTemplate(w, r, "import.html", data)
+		return
+	}
+
+	ids, err := importedIDs(w, r)
+	if err != nil {
+		// Le détail (erreur JSON ou CSV, taille) n'est que journalisé.
+		msg := "invalid favorites file or share token"
+		if !wantsJSON(r) {
+			msg = tr(r, "import.invalid")
+		}
+		RenderError(w, r, http.StatusBadRequest, msg, fmt.Errorf("favorites import: %w", err))
+		return
+	}
+
+	valid := []string{}
+	for _, club := range favoriteClubs(ids) {
+		valid = append(valid, strconv.Itoa(club.ID))
+	}
+	favorites, err := mergeFavorites(w, r, valid)
+	if wantsJSON(r) {
+		writeFavorites(w, r, http.StatusOK, favorites, err)
+		return
+	}
+	if err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("favorites import failed: %w", err))
+		return
+	}
+	http.Redirect(w, r, "/favorites", http.StatusSeeOther)
+}
+
+// importedIDs extrait les IDs à importer d'une requête POST : le jeton de
+// partage `token` s'il est fourni, sinon le fichier `file`.
+func importedIDs(w http.ResponseWriter, r *http.Request) ([]string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+64<<10)
+	if token := r.FormValue("token"); token != "" {
+		return decodeShareToken(token)
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, errors.New("a file or a share token is required")
+	}
+	defer file.Close()
+	b, err := io.ReadAll(io.LimitReader(file, maxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxImportSize {
+		return nil, errors.New("file too large")
+	}
+	return parseFavoritesFile(b)
+}
+
+// parseFavoritesFile lit les IDs d'un fichier de favoris. Sont acceptés :
+// un export JSON (tableau de clubs ou d'IDs), la réponse de
+// `/api/favorites`, ou un CSV dont la colonne `id` (à défaut la première)
+// contient les IDs.
+func parseFavoritesFile(b []byte) ([]string, error) {
+	b = bytes.TrimPrefix(bytes.TrimSpace(b), []byte("\xef\xbb\xbf"))
+	if len(b) == 0 {
+		return nil, errors.New("empty file")
+	}
+	if b[0] == '[' || b[0] == '{' {
+		return parseFavoritesJSON(b)
+	}
+	return parseFavoritesCSV(b)
+}
+
+func parseFavoritesJSON(b []byte) ([]string, error) {
+	var items []json.RawMessage
+	if b[0] == '{' {
+		var payload struct {
+			Favorites []json.RawMessage `json:"favorites"`
+		}
+		if err := json.Unmarshal(b, &payload); err != nil {
+			return nil, fmt.Errorf("invalid JSON file: %w", err)
+		}
+		items = payload.Favorites
+	} else if err := json.Unmarshal(b, &items); err != nil {
+		return nil, fmt.Errorf("invalid JSON file: %w", err)
+	}
+
+	ids := []string{}
+	for _, item := range items {
+		var club struct {
+			ID int `json:"id"`
+		}
+		var id int
+		if err := json.Unmarshal(item, &id); err == nil {
+			ids = append(ids, strconv.Itoa(id))
+		} else if err := json.Unmarshal(item, &club); err == nil && club.ID != 0 {
+			ids = append(ids, strconv.Itoa(club.ID))
+		}
+	}
+	return ids, nil
+}
+
+func parseFavoritesCSV(b []byte) ([]string, error) {
+	cr := csv.NewReader(bufio.NewReader(bytes.NewReader(b)))
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV file: %w", err)
+	}
+	col := 0
+	for i, name := range records[0] {
+		if strings.EqualFold(strings.TrimSpace(name), "id") {
+			col = i
+			records = records[1:]
+			break
+		}
+	}
+	ids := []string{}
+	for _, rec := range records {
+		if col >= len(rec) {
+			continue
+		}
+		if id, err := strconv.Atoi(strings.TrimSpace(rec[col])); err == nil {
+			ids = append(ids, strconv.Itoa(id))
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShareTokenRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []string
+		want []string
+	}{
+		{"single", []string{"1"}, []string{"1"}},
+		{"several", []string{"1", "42", "300", "65"}, []string{"1", "42", "300", "65"}},
+		{"large id", []string{"18446744073709551615"}, []string{"18446744073709551615"}},
+		{"non numeric ids skipped", []string{"7", "abc", "8"}, []string{"7", "8"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeShareToken(encodeShareToken(tt.ids))
+			if err != nil {
+				t.Fatalf("decodeShareToken: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeShareTokenInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"not base64", "@@@"},
+		{"padded base64", "AQ=="},
+		{"truncated varint", "gA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ids, err := decodeShareToken(tt.token); err == nil {
+				t.Errorf("decodeShareToken(%q) = %v, want error", tt.token, ids)
+			}
+		})
+	}
+}
+
+func TestParseFavoritesFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []string
+		wantErr bool
+	}{
+		{"json ids", `[1, 2, 3]`, []string{"1", "2", "3"}, false},
+		{"json clubs", `[{"id": 4, "name": "A"}, {"id": 5}]`, []string{"4", "5"}, false},
+		{"api response", `{"favorites": [{"id": 6}], "total": 1}`, []string{"6"}, false},
+		{"json with bom", "\xef\xbb\xbf[7]", []string{"7"}, false},
+		{"json unknown items skipped", `[8, "x", {"name": "B"}]`, []string{"8"}, false},
+		{"csv id column", "name,id\nA,9\nB,10\n", []string{"9", "10"}, false},
+		{"csv first column", "9\n10\n", []string{"9", "10"}, false},
+		{"empty", "  \n", nil, true},
+		{"invalid json", `[1, 2`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFavoritesFile([]byte(tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
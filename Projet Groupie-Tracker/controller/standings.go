@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"groupie_tracker/models"
+)
+
+type StandingsResponse struct {
+	Standings []models.CompetitionStandings `json:"standings"`
+}
+
+// standingsStore fournit le classement de l'API football-data.org ; nil
+// tant que `SetStandingsStore` n'a pas été appelée (sans clé d'API).
+var standingsStore *models.StandingsStore
+
+// SetStandingsStore définit le store du classement de l'API.
+func SetStandingsStore(s *models.StandingsStore) {
+	standingsStore = s
+}
+
+// loadStandings renvoie le classement de l'API football-data.org si elle
+// est configurée. Sans API, ou si elle est injoignable, il est calculé à
+// partir des matchs terminés (voir `models.ComputeStandings`).
+// Si `competition` n'est pas vide, seul le classement de cette
+// compétition (code insensible à la casse, ex: "PL") est conservé.
+func loadStandings(competition string) []models.CompetitionStandings {
+	var all []models.CompetitionStandings
+	if standingsStore != nil {
+		var err error
+		if all, err = standingsStore.All(); err != nil {
+			log.Printf("computing standings from matches: %v", err)
+		}
+	}
+	if all == nil {
+		all = models.ComputeStandings(loadMatches(), loadClubs())
+	}
+	if competition == "" {
+		return all
+	}
+	filtered := []models.CompetitionStandings{}
+	for _, s := range all {
+		if strings.EqualFold(s.Competition, competition) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+// Standings affiche la page `/standings` : un tableau par compétition
+// (position, club, J, G, N, P, différence de buts, points), rendu avec
+// `standings.html`. Les clubs présents dans le cookie "favorites" sont
+// mis en évidence.
+func Standings(w http.ResponseWriter, r *http.Request) {
+	data := PageData{
+		Title:       tr(r, "standings.title"),
+		Message:     tr(r, "standings.message"),
+		Standings:   loadStandings(r.URL.Query().Get("competition")),
+		FavoriteIDs: favoriteIDSet(r),
+		Meta:        pageMeta(r, "meta.standings"),
+	}
+	renderTemplate(w, r, "standings.html", data)
+}
+
+// StandingsAPI fournit l'endpoint `/api/standings` en JSON, avec un
+// filtre optionnel `competition`.
+func StandingsAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(StandingsResponse{
+		Standings: loadStandings(r.URL.Query().Get("competition")),
+	})
+}
@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"sync"
+
+	groupietracker "groupie_tracker"
+	"groupie_tracker/i18n"
+	"groupie_tracker/middleware"
+	"groupie_tracker/models"
+)
+
+// templates garde les templates HTML analysés une seule fois. En mode
+// développement (`dev`), chaque rendu relit le fichier depuis `fsys` pour
+// que les modifications soient visibles sans redémarrer le serveur.
+var templates = struct {
+	sync.RWMutex
+	fsys fs.FS
+	dev  bool
+	set  map[string]*template.Template
+}{fsys: groupietracker.Templates}
+
+// SetTemplates choisit la source des templates : `fsys` (par défaut les
+// templates embarqués dans le binaire) et le mode `dev`. Hors mode
+// développement, tous les fichiers `*.html` sont analysés immédiatement,
+// et une erreur de syntaxe est renvoyée dès le démarrage.
+func SetTemplates(fsys fs.FS, dev bool) error {
+	var set map[string]*template.Template
+	if !dev {
+		var err error
+		if set, err = parseTemplates(fsys); err != nil {
+			return err
+		}
+	}
+	templates.Lock()
+	defer templates.Unlock()
+	templates.fsys, templates.dev, templates.set = fsys, dev, set
+	return nil
+}
+
+// binding relie un exemplaire de template à la requête en cours de rendu
+// (voir `acquireTemplate`).
+type binding struct {
+	r *http.Request
+}
+
+// boundTemplate est un exemplaire d'un template analysé, dont les
+// fonctions lisent la requête dans `b`. Il ne sert qu'à un rendu à la fois.
+type boundTemplate struct {
+	tmpl *template.Template
+	b    *binding
+}
+
+// templatePools garde, pour chaque template analysé, ses exemplaires
+// libres : le clonage n'a lieu que lorsque tous sont en cours de rendu,
+// et non à chaque requête.
+var templatePools sync.Map // *template.Template -> *sync.Pool
+
+// templateFuncs renvoie les fonctions disponibles dans les templates
+// (`crest` donne l'URL d'un blason redimensionné, voir `crestURL`).
+// `csrfToken`, `csrfField`, `pageURL`, `T`, `lang`, `langURL` et
+// `metaTags` dépendent de la requête liée à `b` : sans requête (à
+// l'analyse), elles renvoient une valeur vide.
+func templateFuncs(b *binding) template.FuncMap {
+	req := func() *http.Request {
+		if b == nil {
+			return nil
+		}
+		return b.r
+	}
+	return template.FuncMap{
+		"toJSON": toJSON,
+		"crest":  crestURL,
+		"metaTags": func(data PageData) template.HTML {
+			r := req()
+			if r == nil {
+				return ""
+			}
+			return metaTags(r, data)
+		},
+		"T": func(key string, args ...interface{}) string {
+			r := req()
+			if r == nil {
+				return ""
+			}
+			return tr(r, key, args...)
+		},
+		"lang": func() string {
+			r := req()
+			if r == nil {
+				return i18n.Default
+			}
+			return i18n.Lang(r)
+		},
+		"langURL": func(lang string) string {
+			r := req()
+			if r == nil {
+				return ""
+			}
+			return langURL(r, lang)
+		},
+		"csrfToken": func() string {
+			r := req()
+			if r == nil {
+				return ""
+			}
+			return middleware.CSRFToken(r)
+		},
+		"csrfField": func() template.HTML {
+			r := req()
+			if r == nil {
+				return ""
+			}
+			return csrfField(r)
+		},
+		"pageURL": func(page int) string {
+			r := req()
+			if r == nil {
+				return ""
+			}
+			return pageURL(r, page)
+		},
+	}
+}
+
+// tr traduit `key` dans la langue de la requête (voir i18n.T).
+func tr(r *http.Request, key string, args ...interface{}) string {
+	return i18n.T(i18n.Lang(r), key, args...)
+}
+
+// trErrors traduit les erreurs de validation d'un modèle (voir
+// `models.FieldError`) dans la langue de la requête.
+func trErrors(r *http.Request, errs map[string]models.FieldError) map[string]string {
+	msgs := make(map[string]string, len(errs))
+	for field, e := range errs {
+		msgs[field] = tr(r, e.Key, e.Args...)
+	}
+	return msgs
+}
+
+// langURL renvoie l'URL de la page courante affichée dans la langue
+// `lang`, en conservant les autres paramètres de la requête.
+func langURL(r *http.Request, lang string) string {
+	q := r.URL.Query()
+	q.Set("lang", lang)
+	return r.URL.Path + "?" + q.Encode()
+}
+
+// parseTemplate analyse le fichier `name` de `fsys`.
+func parseTemplate(fsys fs.FS, name string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs(nil)).ParseFS(fsys, name)
+}
+
+// parseTemplates analyse tous les fichiers `*.html` de `fsys`.
+func parseTemplates(fsys fs.FS) (map[string]*template.Template, error) {
+	names, err := fs.Glob(fsys, "*.html")
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		tmpl, err := parseTemplate(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		set[name] = tmpl
+	}
+	return set, nil
+}
+
+// lookupTemplate renvoie le template `name`, depuis le cache ou, en mode
+// développement, relu depuis le disque. Le cache est construit au premier
+// appel si SetTemplates n'a pas été appelée.
+func lookupTemplate(name string) (*template.Template, error) {
+	templates.RLock()
+	fsys, dev, set := templates.fsys, templates.dev, templates.set
+	templates.RUnlock()
+	if dev {
+		return parseTemplate(fsys, name)
+	}
+	if set == nil {
+		templates.Lock()
+		if templates.set == nil {
+			var err error
+			if templates.set, err = parseTemplates(templates.fsys); err != nil {
+				templates.Unlock()
+				return nil, err
+			}
+		}
+		set = templates.set
+		templates.Unlock()
+	}
+	tmpl, ok := set[name]
+	if !ok {
+		return nil, fmt.Errorf("template %q not found", name)
+	}
+	return tmpl, nil
+}
+
+// acquireTemplate renvoie un exemplaire du template `name` à lier à une
+// requête, et la fonction qui le libère après le rendu. Les exemplaires
+// sont des clones du template analysé, réutilisés d'une requête à l'autre ;
+// en mode développement, le template relu depuis le disque sert directement.
+func acquireTemplate(name string) (*boundTemplate, func(), error) {
+	templates.RLock()
+	dev := templates.dev
+	templates.RUnlock()
+	tmpl, err := lookupTemplate(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dev {
+		bt := &boundTemplate{tmpl: tmpl, b: &binding{}}
+		bt.tmpl.Funcs(templateFuncs(bt.b))
+		return bt, func() {}, nil
+	}
+
+	v, _ := templatePools.LoadOrStore(tmpl, &sync.Pool{})
+	pool := v.(*sync.Pool)
+	bt, _ := pool.Get().(*boundTemplate)
+	if bt == nil {
+		clone, err := tmpl.Clone()
+		if err != nil {
+			return nil, nil, err
+		}
+		bt = &boundTemplate{tmpl: clone, b: &binding{}}
+		bt.tmpl.Funcs(templateFuncs(bt.b))
+	}
+	return bt, func() {
+		bt.b.r = nil
+		pool.Put(bt)
+	}, nil
+}
+
+// renderTemplate exécute le template `filename` avec `data` et écrit la
+// page dans `w` avec le statut 200 (voir `renderPage`).
+func renderTemplate(w http.ResponseWriter, r *http.Request, filename string, data interface{}) {
+	renderPage(w, r, http.StatusOK, filename, data)
+}
+
+// renderPage écrit la page `filename` avec le statut `status` (ex: 422
+// pour un formulaire ré-affiché avec ses erreurs). En cas d'erreur de
+// template, le client reçoit la page d'erreur 500 (voir `RenderError`) et
+// le détail n'est que journalisé.
+func renderPage(w http.ResponseWriter, r *http.Request, status int, filename string, data interface{}) {
+	if err := executeTemplate(w, r, status, filename, data); err != nil {
+		RenderError(w, r, http.StatusInternalServerError, "", fmt.Errorf("template %s: %w", filename, err))
+	}
+}
+
+// executeTemplate exécute le template `filename` avec `data`. Un
+// exemplaire du template (voir `acquireTemplate`) est lié à la requête `r`
+// pour ses fonctions (`csrfField`, champ caché à placer dans chaque
+// formulaire POST, `T`...).
+// La page est produite en mémoire avant d'être envoyée : en cas d'erreur,
+// rien n'est écrit dans `w` et l'erreur est renvoyée.
+func executeTemplate(w http.ResponseWriter, r *http.Request, status int, filename string, data interface{}) error {
+	bt, release, err := acquireTemplate(filename)
+	if err != nil {
+		return err
+	}
+	defer release()
+	bt.b.r = r
+
+	var buf bytes.Buffer
+	if err := bt.tmpl.ExecuteTemplate(&buf, filename, data); err != nil {
+		return err
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+	}
+	buf.WriteTo(w)
+	return nil
+}
@@ -0,0 +1,371 @@
+// Package crests fournit les blasons des clubs redimensionnés aux tailles
+// standard de l'application. Chaque blason est lu une seule fois (fichier
+// statique ou image distante), puis conservé dans un cache sur disque dont
+// la taille est bornée : les fichiers les moins récemment servis sont
+// supprimés en premier.
+package crests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Tailles standard (en pixels, côté du carré englobant) proposées par
+// `/crests/{clubID}?size=` ; DefaultSize est utilisée sans paramètre.
+var Sizes = []int{64, 256}
+
+const DefaultSize = 256
+
+const (
+	// maxSourceBytes limite la taille d'une image source téléchargée.
+	maxSourceBytes = 2 << 20
+	// maxSourcePixels limite les dimensions d'une image source : un petit
+	// fichier compressé peut déclarer une image géante, dont le décodage
+	// allouerait des centaines de Mo.
+	maxSourcePixels = 4096 * 4096
+	// failureTTL est la durée pendant laquelle une source en échec n'est
+	// pas redemandée (les requêtes reçoivent directement le blason de repli).
+	failureTTL = 5 * time.Minute
+)
+
+// ErrUnsupported signale une source qui n'est ni une URL http(s) ni un
+// fichier `/static/`, ou dont le contenu n'est pas une image.
+var ErrUnsupported = errors.New("unsupported crest source")
+
+// Image est un blason prêt à être servi.
+type Image struct {
+	Data        []byte
+	ContentType string
+	// ETag identifie la source et la taille ; il change avec l'URL du blason.
+	ETag string
+}
+
+// Cache récupère, redimensionne et conserve les blasons dans `Dir`.
+type Cache struct {
+	// Dir est le répertoire du cache, créé au premier blason.
+	Dir string
+	// MaxBytes borne la taille totale des fichiers de Dir (<= 0 : pas de limite).
+	MaxBytes int64
+	// Static sert les sources de la forme "/static/...".
+	Static fs.FS
+	// Client télécharge les sources distantes.
+	Client *http.Client
+
+	mu       sync.Mutex
+	locks    map[string]*sync.Mutex // un verrou par fichier du cache
+	failures map[string]time.Time   // source -> fin de l'attente après un échec
+}
+
+// New crée un cache dans `dir` limité à `maxBytes` octets, qui lit les
+// fichiers statiques dans `static`.
+func New(dir string, maxBytes int64, static fs.FS) *Cache {
+	return &Cache{
+		Dir:      dir,
+		MaxBytes: maxBytes,
+		Static:   static,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		locks:    map[string]*sync.Mutex{},
+		failures: map[string]time.Time{},
+	}
+}
+
+// ValidSize indique si `size` fait partie des tailles standard.
+func ValidSize(size int) bool {
+	for _, s := range Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// Version renvoie un identifiant court de la source `src`, à ajouter aux
+// URL des blasons pour que les navigateurs ne gardent pas en cache un
+// blason remplacé.
+func Version(src string) string {
+	return key(src)[:8]
+}
+
+// key est le nom de base des fichiers du cache pour la source `src`.
+func key(src string) string {
+	sum := sha256.Sum256([]byte(src))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Get renvoie le blason `src` redimensionné pour tenir dans un carré de
+// `size` pixels. Le blason est lu dans le cache, ou sinon récupéré à la
+// source puis enregistré. Les images plus petites ne sont pas agrandies ;
+// les SVG sont servis tels quels.
+func (c *Cache) Get(ctx context.Context, src string, size int) (Image, error) {
+	if !ValidSize(size) {
+		return Image{}, fmt.Errorf("invalid crest size %d", size)
+	}
+	name := fmt.Sprintf("%s-%d", key(src), size)
+	lock := c.lock(name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if img, err := c.read(name); err == nil {
+		return img, nil
+	}
+	if err := c.recentFailure(src); err != nil {
+		return Image{}, err
+	}
+
+	data, err := c.fetch(ctx, src)
+	if err == nil {
+		var img Image
+		if img, err = resize(data, size); err == nil {
+			img.ETag = `"` + name + `"`
+			if err := c.write(name, img); err != nil {
+				log.Printf("cannot cache crest %s: %v", src, err)
+			}
+			return img, nil
+		}
+	}
+	if ctx.Err() == nil {
+		c.mu.Lock()
+		c.failures[src] = time.Now().Add(failureTTL)
+		c.mu.Unlock()
+	}
+	return Image{}, err
+}
+
+// lock renvoie le verrou du fichier `name` : deux requêtes simultanées
+// pour le même blason ne le téléchargent qu'une fois.
+func (c *Cache) lock(name string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.locks[name]
+	if !ok {
+		l = &sync.Mutex{}
+		c.locks[name] = l
+	}
+	return l
+}
+
+// recentFailure renvoie une erreur si la source `src` a échoué il y a
+// moins de `failureTTL`.
+func (c *Cache) recentFailure(src string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until, ok := c.failures[src]
+	if !ok {
+		return nil
+	}
+	if time.Now().Before(until) {
+		return fmt.Errorf("crest %s unavailable until %s", src, until.Format(time.TimeOnly))
+	}
+	delete(c.failures, src)
+	return nil
+}
+
+// extensions associe les types servis aux extensions des fichiers du cache.
+var extensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+}
+
+// read lit le fichier `name` du cache et met à jour sa date d'accès (la
+// date de modification sert d'ordre pour l'éviction).
+func (c *Cache) read(name string) (Image, error) {
+	for contentType, ext := range extensions {
+		p := filepath.Join(c.Dir, name+ext)
+		data, err := os.ReadFile(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return Image{}, err
+		}
+		now := time.Now()
+		os.Chtimes(p, now, now)
+		return Image{
+			Data:        data,
+			ContentType: contentType,
+			ETag:        `"` + name + `"`,
+		}, nil
+	}
+	return Image{}, fs.ErrNotExist
+}
+
+// write enregistre `img` sous `name` puis réduit le cache à MaxBytes.
+func (c *Cache) write(name string, img Image) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.Dir, ".crest-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(img.Data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.Dir, name+extensions[img.ContentType])); err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// evict supprime les fichiers les moins récemment servis jusqu'à ce que
+// le cache tienne dans MaxBytes.
+func (c *Cache) evict() error {
+	if c.MaxBytes <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return err
+	}
+	var files []fs.FileInfo
+	var total int64
+	for _, e := range entries {
+		if !e.Type().IsRegular() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	for _, f := range files {
+		if total <= c.MaxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.Dir, f.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= f.Size()
+	}
+	return nil
+}
+
+// fetch lit la source `src` : un fichier statique ("/static/crests/x.png")
+// ou une URL http(s).
+func (c *Cache) fetch(ctx context.Context, src string) ([]byte, error) {
+	if rel, ok := strings.CutPrefix(src, "/static/"); ok {
+		if c.Static == nil || !fs.ValidPath(path.Clean(rel)) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupported, src)
+		}
+		return fs.ReadFile(c.Static, path.Clean(rel))
+	}
+	if !strings.HasPrefix(src, "https://") && !strings.HasPrefix(src, "http://") {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupported, src)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", src, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSourceBytes {
+		return nil, fmt.Errorf("GET %s: image larger than %d bytes", src, maxSourceBytes)
+	}
+	return data, nil
+}
+
+// resize réduit l'image `data` pour qu'elle tienne dans un carré de
+// `size` pixels et la renvoie en PNG. Les SVG et les images déjà assez
+// petites sont renvoyés sans modification ; les dimensions sont lues avant
+// le décodage, qui est refusé au-delà de `maxSourcePixels`.
+func resize(data []byte, size int) (Image, error) {
+	if isSVG(data) {
+		return Image{Data: data, ContentType: "image/svg+xml"}, nil
+	}
+	contentType := http.DetectContentType(data)
+	if _, ok := extensions[contentType]; !ok {
+		return Image{}, fmt.Errorf("%w: content type %s", ErrUnsupported, contentType)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("decode crest: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxSourcePixels {
+		return Image{}, fmt.Errorf("%w: image of %dx%d pixels", ErrUnsupported, cfg.Width, cfg.Height)
+	}
+	if cfg.Width <= size && cfg.Height <= size {
+		return Image{Data: data, ContentType: contentType}, nil
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("decode crest: %w", err)
+	}
+	b := src.Bounds()
+	w, h := size, size
+	if b.Dx() > b.Dy() {
+		h = max(1, b.Dy()*size/b.Dx())
+	} else {
+		w = max(1, b.Dx()*size/b.Dy())
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, dst); err != nil {
+		return Image{}, err
+	}
+	return Image{Data: buf.Bytes(), ContentType: "image/png"}, nil
+}
+
+// isSVG indique si `data` ressemble à un document SVG.
+func isSVG(data []byte) bool {
+	head := data[:min(len(data), 1024)]
+	return bytes.Contains(bytes.ToLower(head), []byte("<svg"))
+}
+
+// Placeholder renvoie un blason de repli en SVG : un écusson gris portant
+// `label` (le TLA du club, par exemple).
+func Placeholder(label string) []byte {
+	label = html.EscapeString(strings.ToUpper(label))
+	return []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100" width="256" height="256">` +
+		`<path d="M50 4 L92 18 V50 C92 74 72 90 50 96 C28 90 8 74 8 50 V18 Z" fill="#d9dde3" stroke="#8a94a3" stroke-width="3"/>` +
+		`<text x="50" y="60" font-family="sans-serif" font-size="22" font-weight="bold" fill="#4a5361" text-anchor="middle">` + label + `</text>` +
+		`</svg>`)
+}
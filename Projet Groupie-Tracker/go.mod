@@ -1,3 +1,8 @@
 module groupie_tracker
 
 go 1.25.1
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.32
+	golang.org/x/image v0.25.0
+)
@@ -0,0 +1,167 @@
+// Package i18n traduit les textes des pages (titres, messages, navigation)
+// en français ou en anglais. Les traductions sont des fichiers JSON
+// `clé -> texte` embarqués dans le binaire (répertoire `locales/`).
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default est la langue utilisée quand aucune préférence n'est reconnue,
+// et pour les clés absentes des autres langues.
+const Default = "fr"
+
+// Cookie mémorise la langue choisie avec le paramètre `?lang=`.
+const Cookie = "lang"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs associe chaque langue à ses traductions.
+var catalogs = mustLoad()
+
+// Languages renvoie les langues disponibles, triées.
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// mustLoad lit les fichiers `locales/<langue>.json`. Un fichier invalide
+// est une erreur de programmation : le binaire refuse de démarrer.
+func mustLoad() map[string]map[string]string {
+	names, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := make(map[string]map[string]string, len(names))
+	for _, entry := range names {
+		b, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(b, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", entry.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	if _, ok := catalogs[Default]; !ok {
+		panic("i18n: missing default locale " + Default)
+	}
+	return catalogs
+}
+
+// T renvoie le texte de `key` dans la langue `lang`, mis en forme avec
+// `args` à la manière de fmt.Sprintf. Une clé absente est cherchée dans la
+// langue par défaut ; à défaut la clé elle-même est renvoyée, ce qui rend
+// les oublis visibles sur la page.
+func T(lang, key string, args ...interface{}) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		if msg, ok = catalogs[Default][key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Supported renvoie la langue disponible correspondant à `tag`
+// ("en", "en-GB", "EN"...), ou "" si elle n'est pas traduite.
+func Supported(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if _, ok := catalogs[tag]; ok {
+		return tag
+	}
+	return ""
+}
+
+// Detect choisit la langue de la requête, dans l'ordre : le paramètre
+// `?lang=`, le cookie `lang`, puis l'en-tête Accept-Language. Sans
+// préférence reconnue, la langue par défaut est renvoyée.
+func Detect(r *http.Request) string {
+	if lang := Supported(r.URL.Query().Get("lang")); lang != "" {
+		return lang
+	}
+	if c, err := r.Cookie(Cookie); err == nil {
+		if lang := Supported(c.Value); lang != "" {
+			return lang
+		}
+	}
+	if lang := fromAcceptLanguage(r.Header.Get("Accept-Language")); lang != "" {
+		return lang
+	}
+	return Default
+}
+
+// fromAcceptLanguage renvoie la langue disponible ayant le plus grand
+// poids `q` dans l'en-tête (ex: "en-US,en;q=0.9,fr;q=0.8"). À poids égal,
+// l'ordre de l'en-tête est conservé.
+func fromAcceptLanguage(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if lang := Supported(tag); lang != "" && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+type contextKey struct{}
+
+// Middleware place la langue de la requête dans son contexte (voir Lang).
+// Une langue choisie avec `?lang=` est mémorisée dans le cookie `lang`
+// (un an, SameSite=Lax) pour les pages suivantes.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := Detect(r)
+		if Supported(r.URL.Query().Get("lang")) != "" {
+			http.SetCookie(w, &http.Cookie{
+				Name:     Cookie,
+				Value:    lang,
+				Path:     "/",
+				MaxAge:   365 * 24 * 3600,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		w.Header().Set("Content-Language", lang)
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, lang)))
+	})
+}
+
+// Lang renvoie la langue placée dans le contexte par Middleware, ou celle
+// détectée sur la requête si le middleware n'est pas installé.
+func Lang(r *http.Request) string {
+	if lang, ok := r.Context().Value(contextKey{}).(string); ok {
+		return lang
+	}
+	return Detect(r)
+}
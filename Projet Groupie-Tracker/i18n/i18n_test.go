@@ -0,0 +1,79 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+// verb repère les verbes de mise en forme d'un texte (`%d`, `%s`...).
+var verb = regexp.MustCompile(`%[a-z]`)
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	def := catalogs[Default]
+	for lang, messages := range catalogs {
+		if lang == Default {
+			continue
+		}
+		for key := range def {
+			if _, ok := messages[key]; !ok {
+				t.Errorf("%s: missing key %q (present in %s)", lang, key, Default)
+			}
+		}
+		for key, msg := range messages {
+			ref, ok := def[key]
+			if !ok {
+				t.Errorf("%s: key %q is missing from %s", lang, key, Default)
+				continue
+			}
+			if got, want := verb.FindAllString(msg, -1), verb.FindAllString(ref, -1); !slices.Equal(got, want) {
+				t.Errorf("%s: %q uses verbs %v, %s uses %v", lang, key, got, Default, want)
+			}
+		}
+	}
+}
+
+// TestTemplateKeysExist vérifie que chaque `{{ T "clé" }}` des templates
+// est traduite dans toutes les langues.
+func TestTemplateKeysExist(t *testing.T) {
+	files, err := filepath.Glob("../template/*.html")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no templates found: %v", err)
+	}
+	use := regexp.MustCompile(`\bT "([^"]+)"`)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range use.FindAllSubmatch(b, -1) {
+			key := string(m[1])
+			for lang, messages := range catalogs {
+				if _, ok := messages[key]; !ok {
+					t.Errorf("%s: key %q is missing from %s", filepath.Base(file), key, lang)
+				}
+			}
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		lang, key string
+		args      []interface{}
+		want      string
+	}{
+		{"fr", "nav.search", nil, "Recherche"},
+		{"en", "nav.search", nil, "Search"},
+		{"en", "error.title", []interface{}{404}, "Error 404"},
+		{"de", "nav.search", nil, "Recherche"},
+		{"en", "no.such.key", nil, "no.such.key"},
+	}
+	for _, tt := range tests {
+		if got := T(tt.lang, tt.key, tt.args...); got != tt.want {
+			t.Errorf("T(%q, %q) = %q, want %q", tt.lang, tt.key, got, tt.want)
+		}
+	}
+}
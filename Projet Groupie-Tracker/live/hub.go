@@ -0,0 +1,150 @@
+// Package live diffuse en temps réel les changements de score et de
+// statut des matchs aux navigateurs connectés. Un `Poller` relit les
+// matchs à intervalle régulier et publie les différences dans un `Hub`,
+// qui les transmet aux abonnés suivant les clubs concernés (en pratique
+// les favoris de chaque visiteur, voir `controller.Events`).
+package live
+
+import (
+	"sort"
+	"sync"
+
+	"groupie_tracker/models"
+)
+
+// subscriptionBuffer est le nombre d'événements en attente par abonné :
+// un abonné trop lent est déconnecté plutôt que de bloquer la diffusion.
+const subscriptionBuffer = 16
+
+// Event signale qu'un match a changé de score ou de statut.
+type Event struct {
+	Match models.Match
+	// Previous est l'état du match lors de la lecture précédente.
+	Previous models.Match
+}
+
+// Subscription reçoit les événements des matchs de ses clubs.
+type Subscription struct {
+	clubs  []int
+	events chan Event
+}
+
+// Events renvoie le canal des événements ; il est fermé quand
+// l'abonnement prend fin (désabonnement, abonné trop lent ou arrêt du hub).
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Hub gère les abonnements, indexés par ID de club. Il peut être utilisé
+// par plusieurs goroutines en parallèle.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	byClub map[int]map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub crée un hub sans abonné.
+func NewHub() *Hub {
+	return &Hub{
+		subs:   map[*Subscription]struct{}{},
+		byClub: map[int]map[*Subscription]struct{}{},
+	}
+}
+
+// Subscribe abonne l'appelant aux matchs des clubs `clubIDs`. Après
+// `Close`, l'abonnement renvoyé est déjà terminé.
+func (h *Hub) Subscribe(clubIDs []int) *Subscription {
+	s := &Subscription{clubs: clubIDs, events: make(chan Event, subscriptionBuffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(s.events)
+		return s
+	}
+	h.subs[s] = struct{}{}
+	for _, id := range clubIDs {
+		if h.byClub[id] == nil {
+			h.byClub[id] = map[*Subscription]struct{}{}
+		}
+		h.byClub[id][s] = struct{}{}
+	}
+	return s
+}
+
+// Unsubscribe termine l'abonnement `s` ; un second appel est sans effet.
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(s)
+}
+
+// removeLocked retire `s` des index et ferme son canal.
+// L'appelant doit détenir h.mu.
+func (h *Hub) removeLocked(s *Subscription) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	for _, id := range s.clubs {
+		delete(h.byClub[id], s)
+		if len(h.byClub[id]) == 0 {
+			delete(h.byClub, id)
+		}
+	}
+	close(s.events)
+}
+
+// Clubs renvoie, triés, les IDs des clubs suivis par au moins un abonné.
+func (h *Hub) Clubs() []int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ids := make([]int, 0, len(h.byClub))
+	for id := range h.byClub {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Subscribers renvoie le nombre d'abonnés connectés.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Publish transmet `ev` aux abonnés de l'équipe à domicile ou à
+// l'extérieur (une seule fois par abonné). Un abonné dont le tampon est
+// plein est désabonné : le navigateur se reconnecte et recharge l'état.
+func (h *Hub) Publish(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sent := map[*Subscription]bool{}
+	for _, id := range []int{ev.Match.HomeTeamID, ev.Match.AwayTeamID} {
+		for s := range h.byClub[id] {
+			if sent[s] {
+				continue
+			}
+			sent[s] = true
+			select {
+			case s.events <- ev:
+			default:
+				h.removeLocked(s)
+			}
+		}
+	}
+}
+
+// Close termine tous les abonnements et refuse les suivants. Il est
+// appelé à l'arrêt du serveur pour que les flux ouverts se terminent
+// sans attendre le délai d'arrêt.
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		h.removeLocked(s)
+	}
+	h.closed = true
+	return nil
+}
@@ -0,0 +1,156 @@
+package live
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+// DefaultInterval est l'intervalle par défaut entre deux lectures des
+// matchs. L'offre gratuite de football-data.org autorise 10 appels par
+// minute, partagés avec le reste de l'application.
+const DefaultInterval = 30 * time.Second
+
+// Poller relit les matchs toutes les `Interval` tant que le hub a des
+// abonnés, et publie les matchs dont le score ou le statut a changé.
+// La dernière liste lue reste disponible avec `Latest`.
+type Poller struct {
+	Hub      *Hub
+	Fetch    func(ctx context.Context) ([]models.Match, error)
+	Interval time.Duration
+
+	last   map[int]models.Match // état de chaque match à la lecture précédente
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+
+	mu        sync.Mutex // protège latest, fetchedAt et err
+	latest    []models.Match
+	fetchedAt time.Time // date de la dernière lecture, réussie ou non
+	err       error     // erreur de la dernière lecture
+}
+
+// NewPoller crée un poller qui lit les matchs avec `fetch` ; un
+// intervalle nul ou négatif vaut DefaultInterval.
+func NewPoller(hub *Hub, fetch func(ctx context.Context) ([]models.Match, error), interval time.Duration) *Poller {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Poller{Hub: hub, Fetch: fetch, Interval: interval}
+}
+
+// Start lance la boucle de lecture dans une goroutine, arrêtée par `Close`.
+func (p *Poller) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel, p.done = cancel, make(chan struct{})
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.Poll(ctx)
+			}
+		}
+	}()
+}
+
+// Close arrête la boucle lancée par Start et attend la fin de la lecture
+// en cours.
+func (p *Poller) Close() error {
+	p.once.Do(func() {
+		if p.cancel != nil {
+			p.cancel()
+			<-p.done
+		}
+	})
+	return nil
+}
+
+// Poll lit les matchs une fois et publie les changements concernant les
+// clubs suivis. Sans abonné, les matchs ne sont pas lus et l'état connu
+// est oublié : il sera repris à la prochaine lecture, sans publier
+// d'événement pour les changements survenus entre-temps.
+func (p *Poller) Poll(ctx context.Context) {
+	clubs := p.Hub.Clubs()
+	if len(clubs) == 0 {
+		p.last = nil
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	matches, err := p.Fetch(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("live: cannot refresh matches: %v", err)
+		}
+		return
+	}
+	p.mu.Lock()
+	p.latest, p.fetchedAt, p.err = matches, time.Now(), nil
+	p.mu.Unlock()
+
+	followed := make(map[int]bool, len(clubs))
+	for _, id := range clubs {
+		followed[id] = true
+	}
+	current := make(map[int]models.Match, len(matches))
+	for _, m := range matches {
+		current[m.ID] = m
+		prev, known := p.last[m.ID]
+		if p.last == nil || !known || !changed(prev, m) {
+			continue
+		}
+		if followed[m.HomeTeamID] || followed[m.AwayTeamID] {
+			p.Hub.Publish(Event{Match: m, Previous: prev})
+		}
+	}
+	p.last = current
+}
+
+// Latest renvoie la dernière liste de matchs lue. Si elle date de plus
+// d'un `Interval` (quand aucun club n'est suivi, Poll ne lit rien), les
+// matchs sont d'abord relus : les appelants déclenchent au plus une
+// lecture par intervalle, quel que soit leur nombre. Si cette lecture
+// échoue, la liste précédente est renvoyée ; l'erreur ne l'est que si
+// aucune liste n'a encore été lue.
+func (p *Poller) Latest(ctx context.Context) ([]models.Match, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.fetchedAt) >= p.Interval {
+		// La lecture sert aussi les appelants en attente du verrou : elle
+		// n'est pas interrompue si `ctx` est annulé.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		matches, err := p.Fetch(ctx)
+		p.fetchedAt = time.Now()
+		if err != nil {
+			log.Printf("live: cannot refresh matches: %v", err)
+			p.err = err
+		} else {
+			p.latest, p.err = matches, nil
+		}
+	}
+	if p.latest == nil && p.err != nil {
+		return nil, p.err
+	}
+	return p.latest, nil
+}
+
+// changed indique si le statut ou le score du match a changé.
+func changed(a, b models.Match) bool {
+	return a.Status != b.Status || !sameScore(a.HomeScore, b.HomeScore) || !sameScore(a.AwayScore, b.AwayScore)
+}
+
+func sameScore(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
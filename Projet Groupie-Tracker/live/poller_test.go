@@ -0,0 +1,49 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+func TestPollerLatest(t *testing.T) {
+	calls := 0
+	var fail error
+	p := NewPoller(NewHub(), func(context.Context) ([]models.Match, error) {
+		calls++
+		if fail != nil {
+			return nil, fail
+		}
+		return []models.Match{{ID: calls}}, nil
+	}, time.Hour)
+	ctx := context.Background()
+
+	fail = errors.New("upstream down")
+	if _, err := p.Latest(ctx); err == nil {
+		t.Fatal("Latest() without any match read: want an error")
+	}
+
+	fail = nil
+	p.fetchedAt = time.Time{}
+	got, err := p.Latest(ctx)
+	if err != nil || len(got) != 1 || got[0].ID != 2 {
+		t.Fatalf("Latest() = %v, %v; want match 2", got, err)
+	}
+	if got, _ := p.Latest(ctx); calls != 2 || got[0].ID != 2 {
+		t.Errorf("Latest() within the interval read the matches again (%d calls)", calls)
+	}
+
+	// Liste trop ancienne et lecture en échec : la liste précédente est servie.
+	fail = errors.New("upstream down")
+	p.fetchedAt = time.Time{}
+	if got, err := p.Latest(ctx); err != nil || got[0].ID != 2 {
+		t.Errorf("Latest() after a failed refresh = %v, %v; want match 2", got, err)
+	}
+	p.Latest(ctx)
+	if calls != 3 {
+		t.Errorf("failed refresh retried within the interval (%d calls)", calls)
+	}
+}
@@ -0,0 +1,123 @@
+// Package mailer envoie par SMTP les notifications de l'application
+// (messages du formulaire de contact).
+package mailer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+// DefaultTimeout borne la durée totale d'un envoi (connexion comprise)
+// quand `Mailer.Timeout` n'est pas renseigné.
+const DefaultTimeout = 30 * time.Second
+
+// Mailer envoie des e-mails via le serveur SMTP `Addr` ("hôte:port").
+// Si `Username` est renseigné, l'authentification PLAIN est utilisée
+// (le serveur doit alors proposer STARTTLS, sauf sur localhost).
+type Mailer struct {
+	Addr     string
+	Username string
+	Password string
+	From     string        // expéditeur des notifications
+	To       string        // destinataire des messages de contact
+	Timeout  time.Duration // durée maximale d'un envoi (DefaultTimeout si nul)
+}
+
+// headerSanitizer retire les retours à la ligne d'une valeur d'en-tête,
+// pour qu'un champ saisi par un visiteur ne puisse pas ajouter d'en-têtes.
+var headerSanitizer = strings.NewReplacer("\r", " ", "\n", " ")
+
+// SendContact transmet le message de contact `msg` à `To`. La réponse
+// au message (Reply-To) est adressée directement au visiteur.
+func (m *Mailer) SendContact(msg models.ContactMessage) error {
+	subject := "Nouveau message de contact : " + msg.Name
+	body := fmt.Sprintf("Nom : %s\nE-mail : %s\nDate : %s\n\n%s\n",
+		msg.Name, msg.Email, msg.CreatedAt.Format(time.RFC1123Z), msg.Message)
+	return m.send(subject, msg.Email, body)
+}
+
+// send compose un e-mail texte UTF-8 et l'envoie.
+func (m *Mailer) send(subject, replyTo, body string) error {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, headerSanitizer.Replace(value))
+	}
+	header("From", m.From)
+	header("To", m.To)
+	if replyTo != "" {
+		header("Reply-To", replyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", headerSanitizer.Replace(subject)))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "8bit")
+	buf.WriteString("\r\n")
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return m.deliver(buf.Bytes())
+}
+
+// deliver envoie `msg` comme smtp.SendMail (STARTTLS si le serveur le
+// propose, puis authentification), mais avec un délai : un serveur SMTP
+// qui ne répond pas ne bloque pas l'envoi plus de `Timeout`.
+func (m *Mailer) deliver(msg []byte) error {
+	host, _, err := net.SplitHostPort(m.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address %q: %w", m.Addr, err)
+	}
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	conn, err := net.DialTimeout("tcp", m.Addr, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(m.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+var (
+	genCities = []string{
+		"Ashford", "Bramley", "Carrow", "Dunmore", "Eastleigh", "Fairview",
+		"Glenford", "Harrowby", "Ilkston", "Kingsbury", "Lynwood", "Marston",
+		"Northam", "Oakridge", "Penrith", "Queensby", "Redcliffe", "Stanmore",
+		"Thornbury", "Upton", "Westbrook", "Yarmouth",
+	}
+	genSuffixes = []string{
+		"United", "City", "Rovers", "Athletic", "Wanderers", "Town",
+		"Albion", "Rangers", "County", "FC",
+	}
+	genVenues = []string{"Stadium", "Park", "Arena", "Ground", "Road"}
+
+	genFirstNames = []string{
+		"Adam", "Ben", "Carlos", "Daniel", "Eric", "Felix", "Gabriel", "Hugo",
+		"Ivan", "Jonas", "Kevin", "Lucas", "Marco", "Nathan", "Oscar", "Pablo",
+		"Rafael", "Samuel", "Tom", "Victor", "William", "Yann",
+	}
+	genLastNames = []string{
+		"Anderson", "Bernard", "Costa", "Dubois", "Evans", "Fischer", "Garcia",
+		"Hansen", "Iversen", "Jensen", "Kowalski", "Lopez", "Martin", "Novak",
+		"Olsen", "Petit", "Rossi", "Silva", "Taylor", "Weber",
+	}
+	genNationalities = []string{
+		"England", "France", "Spain", "Germany", "Italy", "Portugal",
+		"Netherlands", "Belgium", "Brazil", "Argentina",
+	}
+	// genPositions est la composition d'un effectif, répétée si besoin.
+	genPositions = []string{
+		"Goalkeeper", "Defence", "Defence", "Defence", "Defence", "Midfield",
+		"Midfield", "Midfield", "Midfield", "Offence", "Offence", "Goalkeeper",
+		"Defence", "Defence", "Midfield", "Midfield", "Offence", "Offence",
+	}
+	// genSeasonStart est la date de la première journée des matchs générés.
+	genSeasonStart = time.Date(2026, time.August, 15, 14, 0, 0, 0, time.UTC)
+)
+
+// runGen implémente la sous-commande `gen`.
+// Elle produit `competitions × clubs` clubs fictifs mais réalistes
+// (nom, TLA, stade, année de fondation, site web) au format de
+// `data/clubs.json`, de façon reproductible à partir de `-seed`.
+// Avec `-squads` et `-matches`, elle écrit aussi leurs effectifs et un
+// calendrier aller-retour par compétition (les `-played` premières
+// journées sont terminées), qui utilisent les IDs des clubs générés.
+// Exemple: `go run ./main gen -competitions 10 -clubs 50 -out /tmp/clubs.json
+// -squads /tmp/squads.json -matches /tmp/matches.json`.
+func runGen(args []string) {
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	competitions := fs.Int("competitions", 5, "nombre de compétitions")
+	perCompetition := fs.Int("clubs", 20, "nombre de clubs par compétition")
+	players := fs.Int("players", 18, "nombre de joueurs par club (avec -squads)")
+	played := fs.Int("played", 10, "nombre de journées terminées (avec -matches)")
+	seed := fs.Int64("seed", 1, "graine du générateur aléatoire")
+	out := fs.String("out", "", "fichier de sortie des clubs (sortie standard si vide)")
+	squadsOut := fs.String("squads", "", "fichier de sortie des effectifs (non générés si vide)")
+	matchesOut := fs.String("matches", "", "fichier de sortie des matchs (non générés si vide)")
+	fs.Parse(args)
+
+	if *competitions <= 0 || *perCompetition <= 0 || *players <= 0 || *played < 0 {
+		log.Fatal("gen: -competitions, -clubs et -players doivent être positifs, -played aussi")
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	clubs := generateClubs(rng, *competitions, *perCompetition)
+	writeGenerated(*out, clubs, "clubs")
+	if *squadsOut != "" {
+		writeGenerated(*squadsOut, generateSquads(rng, clubs, *players), "players")
+	}
+	if *matchesOut != "" {
+		writeGenerated(*matchesOut, generateMatches(rng, clubs, *played), "matches")
+	}
+}
+
+// writeGenerated écrit `items` en JSON indenté dans `path`, ou sur la
+// sortie standard si `path` est vide.
+func writeGenerated[T any](path string, items []T, what string) {
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(items); err != nil {
+		log.Fatal(err)
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "%d %s written to %s\n", len(items), what, path)
+	}
+}
+
+// generateClubs construit la liste des clubs fictifs. Les noms sont
+// rendus uniques en ajoutant un numéro lorsque la combinaison
+// ville/suffixe a déjà été utilisée.
+func generateClubs(rng *rand.Rand, competitions, perCompetition int) []models.Club {
+	clubs := make([]models.Club, 0, competitions*perCompetition)
+	used := make(map[string]bool)
+	id := 1
+	for c := 1; c <= competitions; c++ {
+		for i := 0; i < perCompetition; i++ {
+			city := genCities[rng.Intn(len(genCities))]
+			base := city + " " + genSuffixes[rng.Intn(len(genSuffixes))]
+			name := base
+			for n := 2; used[name]; n++ {
+				name = fmt.Sprintf("%s %d", base, n)
+			}
+			used[name] = true
+
+			slug := strings.ToLower(strings.ReplaceAll(name, " ", ""))
+			clubs = append(clubs, models.Club{
+				ID:          id,
+				Name:        name,
+				ShortName:   city,
+				TLA:         strings.ToUpper(city[:3]),
+				Website:     "https://www." + slug + ".example",
+				Founded:     1860 + rng.Intn(130),
+				Venue:       city + " " + genVenues[rng.Intn(len(genVenues))],
+				City:        city,
+				Competition: genCompetition(c),
+			})
+			id++
+		}
+	}
+	return clubs
+}
+
+// genCompetition renvoie le code de la compétition générée `n` (à partir
+// de 1), utilisé par les clubs et par les matchs.
+func genCompetition(n int) string {
+	return fmt.Sprintf("SL%d", n)
+}
+
+// generateSquads construit `perClub` joueurs pour chaque club de `clubs`,
+// avec des IDs uniques et des numéros de maillot distincts dans un club.
+func generateSquads(rng *rand.Rand, clubs []models.Club, perClub int) []models.Player {
+	players := make([]models.Player, 0, len(clubs)*perClub)
+	id := 1
+	for _, club := range clubs {
+		for i := 0; i < perClub; i++ {
+			dob := time.Date(1990+rng.Intn(18), time.Month(1+rng.Intn(12)), 1+rng.Intn(28), 0, 0, 0, 0, time.UTC)
+			players = append(players, models.Player{
+				ID:          id,
+				ClubID:      club.ID,
+				Name:        genFirstNames[rng.Intn(len(genFirstNames))] + " " + genLastNames[rng.Intn(len(genLastNames))],
+				Position:    genPositions[i%len(genPositions)],
+				Nationality: genNationalities[rng.Intn(len(genNationalities))],
+				DateOfBirth: dob.Format("2006-01-02"),
+				ShirtNumber: i + 1,
+			})
+			id++
+		}
+	}
+	return players
+}
+
+// generateMatches construit un championnat aller-retour pour chaque
+// compétition des clubs (méthode du tourniquet), une journée par semaine
+// à partir de `genSeasonStart`. Les `played` premières journées sont
+// terminées avec un score aléatoire, les suivantes programmées.
+func generateMatches(rng *rand.Rand, clubs []models.Club, played int) []models.Match {
+	byCompetition := map[string][]int{}
+	var codes []string
+	for _, c := range clubs {
+		if _, ok := byCompetition[c.Competition]; !ok {
+			codes = append(codes, c.Competition)
+		}
+		byCompetition[c.Competition] = append(byCompetition[c.Competition], c.ID)
+	}
+
+	matches := []models.Match{}
+	id := 1
+	for _, code := range codes {
+		for day, pairs := range roundRobin(byCompetition[code]) {
+			for _, pair := range pairs {
+				m := models.Match{
+					ID:          id,
+					Competition: code,
+					Matchday:    day + 1,
+					UtcDate:     genSeasonStart.AddDate(0, 0, 7*day),
+					Status:      "SCHEDULED",
+					HomeTeamID:  pair[0],
+					AwayTeamID:  pair[1],
+				}
+				if day < played {
+					home, away := rng.Intn(5), rng.Intn(4)
+					m.Status, m.HomeScore, m.AwayScore = "FINISHED", &home, &away
+				}
+				matches = append(matches, m)
+				id++
+			}
+		}
+	}
+	return matches
+}
+
+// roundRobin renvoie les journées d'un championnat aller-retour entre
+// les clubs `ids` : chaque journée est une liste de paires (domicile,
+// extérieur). Avec un nombre impair de clubs, l'un d'eux est exempt à
+// chaque journée.
+func roundRobin(ids []int) [][][2]int {
+	teams := append([]int(nil), ids...)
+	if len(teams)%2 == 1 {
+		teams = append(teams, 0) // 0 : exempt
+	}
+	n := len(teams)
+	if n < 2 {
+		return nil
+	}
+	var first [][][2]int
+	for round := 0; round < n-1; round++ {
+		var day [][2]int
+		for i := 0; i < n/2; i++ {
+			home, away := teams[i], teams[n-1-i]
+			if (round+i)%2 == 1 {
+				home, away = away, home
+			}
+			if home != 0 && away != 0 {
+				day = append(day, [2]int{home, away})
+			}
+		}
+		first = append(first, day)
+		// Le premier club reste en place, les autres tournent.
+		teams = append([]int{teams[0], teams[n-1]}, teams[1:n-1]...)
+	}
+	days := first
+	for _, day := range first {
+		var back [][2]int
+		for _, p := range day {
+			back = append(back, [2]int{p[1], p[0]})
+		}
+		days = append(days, back)
+	}
+	return days
+}
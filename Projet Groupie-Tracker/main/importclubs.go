@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"groupie_tracker/config"
+	"groupie_tracker/models"
+	"groupie_tracker/storage"
+)
+
+// runImportClubs implémente la sous-commande `import-clubs`.
+// Elle lit un fichier JSON de clubs (par défaut `data/clubs.json`) et
+// remplace le contenu de la table `clubs` de la base SQLite, utilisée
+// quand le serveur est lancé avec `CLUBS_BACKEND=sqlite`.
+// Exemple: `go run ./main import-clubs data/clubs.json`.
+func runImportClubs(args []string) {
+	fs := flag.NewFlagSet("import-clubs", flag.ExitOnError)
+	defaultDB := os.Getenv("GROUPIE_DB")
+	if defaultDB == "" {
+		defaultDB = "data/groupie.db"
+	}
+	dbPath := fs.String("db", defaultDB, "chemin de la base SQLite")
+	fs.Parse(args)
+
+	path := config.Default().ClubsFile
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+
+	clubs, err := models.LoadClubsFromFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	repo, err := storage.OpenSQLite(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repo.Close()
+	if err := repo.Import(clubs); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d clubs imported into %s\n", len(clubs), *dbPath)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var loadTestSearches = []string{"", "", "man", "united", "city", "ars", "park", "ton", "zzz"}
+
+// runLoadTest implémente la sous-commande `loadtest`.
+// Elle envoie `-n` requêtes à `/api/clubs` avec `-c` clients concurrents,
+// en mélangeant recherche textuelle, plages d'années et pagination,
+// puis affiche le débit et les percentiles de latence. Les requêtes sont
+// tirées à partir de `-seed` pour que deux mesures soient comparables.
+// Exemple: `go run ./main loadtest -url http://localhost:8080 -n 5000 -c 20`.
+func runLoadTest(args []string) {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	baseURL := fs.String("url", "http://localhost:8080", "URL du serveur à tester")
+	total := fs.Int("n", 1000, "nombre total de requêtes")
+	concurrency := fs.Int("c", 10, "nombre de clients concurrents")
+	seed := fs.Int64("seed", 1, "graine du générateur de requêtes")
+	fs.Parse(args)
+
+	if *total <= 0 || *concurrency <= 0 {
+		log.Fatal("loadtest: -n et -c doivent être positifs")
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	urls := make(chan string, *total)
+	for i := 0; i < *total; i++ {
+		urls <- *baseURL + "/api/clubs?" + randomClubQuery(rng).Encode()
+	}
+	close(urls)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		failures  int
+		wg        sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range urls {
+				t0 := time.Now()
+				resp, err := client.Get(u)
+				ok := err == nil && resp.StatusCode == http.StatusOK
+				if err == nil {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+				d := time.Since(t0)
+
+				mu.Lock()
+				latencies = append(latencies, d)
+				if !ok {
+					failures++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	fmt.Printf("requests: %d, failures: %d, duration: %s\n", len(latencies), failures, elapsed.Round(time.Millisecond))
+	fmt.Printf("throughput: %.1f req/s\n", float64(len(latencies))/elapsed.Seconds())
+	fmt.Printf("latency p50: %s, p90: %s, p99: %s, max: %s\n",
+		percentile(latencies, 50), percentile(latencies, 90),
+		percentile(latencies, 99), latencies[len(latencies)-1])
+}
+
+// randomClubQuery tire une combinaison de filtres pour `/api/clubs`.
+func randomClubQuery(rng *rand.Rand) url.Values {
+	q := url.Values{}
+	if s := loadTestSearches[rng.Intn(len(loadTestSearches))]; s != "" {
+		q.Set("search", s)
+	}
+	if rng.Intn(3) == 0 {
+		q.Set("minYear", strconv.Itoa(1860+rng.Intn(60)))
+	}
+	if rng.Intn(3) == 0 {
+		q.Set("maxYear", strconv.Itoa(1900+rng.Intn(90)))
+	}
+	q.Set("page", strconv.Itoa(1+rng.Intn(3)))
+	q.Set("pageSize", strconv.Itoa([]int{6, 12, 24}[rng.Intn(3)]))
+	return q
+}
+
+// percentile renvoie le p-ième percentile d'une liste de durées triée.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i].Round(time.Microsecond)
+}
@@ -1,24 +1,106 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"groupie_tracker/config"
 	"groupie_tracker/router"
 )
 
 // main démarre le serveur HTTP de l'application.
-// Il crée le routeur, affiche l'URL d'écoute et lance `http.ListenAndServe`.
+// La configuration est lue par `config.Load` (variables d'environnement
+// et flags, voir `-h`), puis un `http.Server` avec délais de lecture et
+// d'écriture est lancé, en HTTPS si un certificat est fourni.
+// À la réception de SIGINT ou SIGTERM, le serveur cesse d'accepter des
+// connexions, termine les flux `/events` et laisse aux requêtes en cours
+// `ShutdownTimeout` pour se terminer avant de fermer les bases de données.
+// Les sous-commandes `gen` (jeu de données synthétique), `loadtest`
+// (test de charge de `/api/clubs`) et `import-clubs` (remplissage de la
+// base SQLite) sont traitées avant le démarrage.
 func main() {
-	mux := router.New()
-	addr := ":8080"
-	fullURL := "http://localhost" + addr
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "gen":
+			runGen(os.Args[2:])
+			return
+		case "loadtest":
+			runLoadTest(os.Args[2:])
+			return
+		case "import-clubs":
+			runImportClubs(os.Args[2:])
+			return
+		}
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           router.New(cfg),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	srv.RegisterOnShutdown(router.Shutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	
-	fmt.Println(fullURL)
+	errc := make(chan error, 1)
+	go func() {
+		if cfg.TLSEnabled() {
+			errc <- srv.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+		} else {
+			errc <- srv.ListenAndServe()
+		}
+	}()
+	fmt.Println(serverURL(cfg))
 
-	if err := http.ListenAndServe(addr, mux); err != nil {
+	select {
+	case err := <-errc:
+		router.Close()
 		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	stop()
+	log.Printf("shutting down, waiting up to %s for in-flight requests", cfg.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	if err := router.Close(); err != nil {
+		log.Printf("closing resources: %v", err)
+	}
+	log.Print("server stopped")
+}
+
+// serverURL renvoie l'URL locale affichée au démarrage.
+func serverURL(cfg *config.Config) string {
+	scheme := "http"
+	if cfg.TLSEnabled() {
+		scheme = "https"
+	}
+	host := cfg.Addr
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
 	}
+	return scheme + "://" + host
 }
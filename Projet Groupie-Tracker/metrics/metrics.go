@@ -0,0 +1,305 @@
+// Package metrics collecte des compteurs et des histogrammes en mémoire
+// et les expose au format texte de Prometheus (voir Handler).
+//
+// Les métriques sont créées une fois, au niveau du package qui les
+// alimente (NewCounterVec, NewHistogramVec, NewGaugeFunc), puis
+// enregistrées automatiquement dans le registre global.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// collector est une métrique capable de s'écrire au format texte.
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+var registry struct {
+	sync.Mutex
+	collectors []collector
+}
+
+func register(c collector) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.collectors = append(registry.collectors, c)
+}
+
+// series est un ensemble de valeurs de labels, dans l'ordre des noms de
+// labels de la métrique.
+type series []string
+
+func (s series) key() string {
+	return strings.Join(s, "\xff")
+}
+
+// labels met en forme `{nom="valeur",...}`, avec éventuellement un label
+// supplémentaire `extra` (ex: `le` des histogrammes).
+func labels(names []string, values series, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", name, labelEscaper.Replace(values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", extra[i], labelEscaper.Replace(extra[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// checkLabels panique si le nombre de valeurs ne correspond pas aux
+// labels déclarés : c'est une erreur de programmation.
+func checkLabels(metric string, names []string, values []string) {
+	if len(values) != len(names) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", metric, len(names), len(values)))
+	}
+}
+
+// CounterVec est un compteur croissant, décliné par valeurs de labels.
+type CounterVec struct {
+	metric, help string
+	labelNames   []string
+	mu           sync.Mutex
+	values       map[string]float64
+	series       map[string]series
+}
+
+// NewCounterVec crée et enregistre le compteur `name` avec les labels
+// `labelNames`.
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		metric: name, help: help, labelNames: labelNames,
+		values: map[string]float64{}, series: map[string]series{},
+	}
+	register(c)
+	return c
+}
+
+// Inc ajoute 1 à la série `values`.
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add ajoute `v` (positif) à la série `values`.
+func (c *CounterVec) Add(v float64, values ...string) {
+	checkLabels(c.metric, c.labelNames, values)
+	s := series(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := s.key()
+	if _, ok := c.series[k]; !ok {
+		c.series[k] = append(series(nil), s...)
+	}
+	c.values[k] += v
+}
+
+// Value renvoie la valeur actuelle de la série `values`.
+func (c *CounterVec) Value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[series(values).key()]
+}
+
+func (c *CounterVec) name() string { return c.metric }
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.metric, c.help, "counter")
+	for _, k := range sortedKeys(c.series) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metric, labels(c.labelNames, c.series[k]), formatFloat(c.values[k]))
+	}
+}
+
+// DefaultBuckets sont les bornes (en secondes) adaptées aux durées de
+// requêtes HTTP.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramVec répartit des observations (ex: durées) dans des intervalles
+// cumulés, déclinés par valeurs de labels.
+type HistogramVec struct {
+	metric, help string
+	labelNames   []string
+	buckets      []float64
+	mu           sync.Mutex
+	data         map[string]*histogram
+}
+
+type histogram struct {
+	series series
+	counts []uint64 // une case par borne de `buckets`
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec crée et enregistre l'histogramme `name` avec les bornes
+// `buckets` (DefaultBuckets si nil) et les labels `labelNames`.
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{
+		metric: name, help: help, labelNames: labelNames,
+		buckets: buckets, data: map[string]*histogram{},
+	}
+	register(h)
+	return h
+}
+
+// Observe ajoute l'observation `v` à la série `values`.
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	checkLabels(h.metric, h.labelNames, values)
+	s := series(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := s.key()
+	d, ok := h.data[k]
+	if !ok {
+		d = &histogram{series: append(series(nil), s...), counts: make([]uint64, len(h.buckets))}
+		h.data[k] = d
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			d.counts[i]++
+		}
+	}
+	d.count++
+	d.sum += v
+}
+
+func (h *HistogramVec) name() string { return h.metric }
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.metric, h.help, "histogram")
+	for _, k := range sortedKeys(h.data) {
+		d := h.data[k]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metric, labels(h.labelNames, d.series, "le", formatFloat(upper)), d.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metric, labels(h.labelNames, d.series, "le", "+Inf"), d.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metric, labels(h.labelNames, d.series), formatFloat(d.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metric, labels(h.labelNames, d.series), d.count)
+	}
+}
+
+// GaugeFunc est une jauge calculée au moment de l'export, par exemple un
+// taux dérivé de compteurs ou le nombre de goroutines.
+type GaugeFunc struct {
+	metric, help string
+	label        string
+	fn           func() map[string]float64
+}
+
+// NewGaugeFunc crée et enregistre la jauge `name`. `fn` renvoie la valeur
+// de chaque série, indexée par la valeur du label `label` ; si `label` est
+// vide, seule la valeur de clé "" est exportée, sans label.
+func NewGaugeFunc(name, help, label string, fn func() map[string]float64) *GaugeFunc {
+	g := &GaugeFunc{metric: name, help: help, label: label, fn: fn}
+	register(g)
+	return g
+}
+
+func (g *GaugeFunc) name() string { return g.metric }
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	values := g.fn()
+	writeHeader(w, g.metric, g.help, "gauge")
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if g.label == "" {
+			if k == "" {
+				fmt.Fprintf(w, "%s %s\n", g.metric, formatFloat(values[k]))
+			}
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %s\n", g.metric, labels([]string{g.label}, series{k}), formatFloat(values[k]))
+	}
+}
+
+// Métriques du processus, toujours exportées.
+func init() {
+	start := float64(time.Now().Unix())
+	NewGaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", "",
+		func() map[string]float64 { return map[string]float64{"": start} })
+	NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", "",
+		func() map[string]float64 { return map[string]float64{"": float64(runtime.NumGoroutine())} })
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteText écrit toutes les métriques enregistrées au format texte de
+// Prometheus (version 0.0.4), triées par nom.
+func WriteText(w io.Writer) error {
+	registry.Lock()
+	collectors := append([]collector(nil), registry.collectors...)
+	registry.Unlock()
+	sort.SliceStable(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler sert les métriques pour le scraping Prometheus (`/metrics`).
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		WriteText(w)
+	})
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"groupie_tracker/i18n"
+)
+
+const (
+	// CSRFCookie est le cookie portant le jeton CSRF de la session du navigateur.
+	CSRFCookie = "csrf_token"
+	// CSRFField est le champ de formulaire attendu dans les requêtes POST
+	// url-encoded, et le paramètre d'URL attendu pour les envois multipart.
+	CSRFField = "csrf_token"
+	// CSRFHeader est l'en-tête accepté à la place du champ (requêtes fetch/XHR).
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// csrfMaxForm limite la taille d'un corps url-encoded lu pour trouver le
+// champ CSRF (celle d'un formulaire de contact) ; au-delà, le jeton doit
+// être envoyé dans l'en-tête.
+const csrfMaxForm = 64 << 10
+
+type csrfKey struct{}
+
+// CSRF protège les requêtes modifiant l'état (POST, PUT, PATCH, DELETE).
+// Chaque navigateur reçoit un jeton aléatoire dans le cookie `csrf_token`
+// (HttpOnly, SameSite=Lax, durée de la session du navigateur) ; les
+// formulaires le recopient dans le champ caché `csrf_token` (voir
+// CSRFToken), ou dans le paramètre d'URL `csrf_token` pour les envois de
+// fichiers (multipart), dont le corps n'est pas lu ici ; les appels
+// JavaScript, qui ne peuvent pas lire le cookie, le lisent dans la balise
+// `<meta name="csrf-token">` des pages et l'envoient dans l'en-tête
+// `X-CSRF-Token`.
+// Une requête dont le jeton est absent ou différent du cookie est rejetée
+// avec 403, en JSON pour `/api/*`.
+func CSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+		if c, err := r.Cookie(CSRFCookie); err == nil && validCSRFToken(c.Value) {
+			token = c.Value
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		default:
+			if token == "" || !sameToken(token, submittedToken(r)) {
+				rejectCSRF(w, r)
+				return
+			}
+		}
+
+		if token == "" {
+			token = newCSRFToken()
+			http.SetCookie(w, &http.Cookie{
+				Name:     CSRFCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfKey{}, token)))
+	})
+}
+
+// CSRFToken renvoie le jeton CSRF de la requête, à intégrer aux formulaires.
+// Il est vide si la requête n'est pas passée par le middleware CSRF.
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfKey{}).(string)
+	return token
+}
+
+// newCSRFToken génère un jeton aléatoire de 32 octets en hexadécimal.
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("csrf: cannot read random bytes: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// validCSRFToken vérifie qu'un cookie a le format d'un jeton généré.
+func validCSRFToken(token string) bool {
+	if len(token) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(token)
+	return err == nil
+}
+
+// sameToken compare deux jetons en temps constant.
+func sameToken(a, b string) bool {
+	return b != "" && subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// submittedToken lit le jeton envoyé dans l'en-tête `X-CSRF-Token`, sinon
+// dans le paramètre d'URL `csrf_token` pour un corps multipart, ou dans le
+// champ `csrf_token` d'un corps url-encoded d'au plus `csrfMaxForm` octets.
+// Le corps lu est restauré, pour que les handlers (et
+// `controller.Idempotent`) le retrouvent intact.
+func submittedToken(r *http.Request) string {
+	if h := r.Header.Get(CSRFHeader); h != "" {
+		return h
+	}
+	ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case ct == "multipart/form-data":
+		return r.URL.Query().Get(CSRFField)
+	case ct != "application/x-www-form-urlencoded" || r.Body == nil:
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, csrfMaxForm+1))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil || len(body) > csrfMaxForm {
+		return ""
+	}
+	values, _ := url.ParseQuery(string(body))
+	return values.Get(CSRFField)
+}
+
+// rejectCSRF répond 403 : message technique pour l'API, invitation
+// traduite à recharger la page pour les formulaires.
+func rejectCSRF(w http.ResponseWriter, r *http.Request) {
+	msg := "invalid or missing CSRF token"
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		msg = i18n.T(i18n.Lang(r), "error.csrf")
+	}
+	renderError(w, r, http.StatusForbidden, msg, nil)
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	token := strings.Repeat("ab", 32)
+	form := url.Values{CSRFField: {token}, "name": {"x"}}.Encode()
+
+	var multi bytes.Buffer
+	mw := multipart.NewWriter(&multi)
+	mw.WriteField(CSRFField, token)
+	mw.WriteField("name", "x")
+	mw.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		query       string
+		cookie      string
+		header      string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCookie  bool
+	}{
+		{name: "get issues a token", method: http.MethodGet, wantStatus: http.StatusOK, wantCookie: true},
+		{name: "get keeps a valid token", method: http.MethodGet, cookie: token, wantStatus: http.StatusOK},
+		{name: "get replaces a malformed token", method: http.MethodGet, cookie: "bad", wantStatus: http.StatusOK, wantCookie: true},
+		{name: "post without cookie", method: http.MethodPost, header: token, wantStatus: http.StatusForbidden},
+		{name: "post without token", method: http.MethodPost, cookie: token, wantStatus: http.StatusForbidden},
+		{name: "post with header", method: http.MethodPost, cookie: token, header: token, wantStatus: http.StatusOK},
+		{name: "post with wrong header", method: http.MethodPost, cookie: token, header: strings.Repeat("cd", 32), wantStatus: http.StatusForbidden},
+		{name: "post with malformed cookie", method: http.MethodPost, cookie: "bad", header: "bad", wantStatus: http.StatusForbidden},
+		{
+			name: "post with form field", method: http.MethodPost, cookie: token,
+			contentType: "application/x-www-form-urlencoded", body: form, wantStatus: http.StatusOK,
+		},
+		{
+			name: "post with form field and charset", method: http.MethodPost, cookie: token,
+			contentType: "application/x-www-form-urlencoded; charset=utf-8", body: form, wantStatus: http.StatusOK,
+		},
+		{
+			name: "form field in an oversized form", method: http.MethodPost, cookie: token,
+			contentType: "application/x-www-form-urlencoded",
+			body:        form + "&pad=" + strings.Repeat("x", csrfMaxForm), wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "form field with another content type", method: http.MethodPost, cookie: token,
+			contentType: "text/plain", body: form, wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "multipart field is not read", method: http.MethodPost, cookie: token,
+			contentType: mw.FormDataContentType(), body: multi.String(), wantStatus: http.StatusForbidden,
+		},
+		{
+			name: "multipart with query token", method: http.MethodPost, query: "?" + CSRFField + "=" + token, cookie: token,
+			contentType: mw.FormDataContentType(), body: multi.String(), wantStatus: http.StatusOK,
+		},
+		{
+			name: "query token without multipart", method: http.MethodPost, query: "?" + CSRFField + "=" + token, cookie: token,
+			contentType: "application/x-www-form-urlencoded", body: "name=x", wantStatus: http.StatusForbidden,
+		},
+		{name: "delete with header", method: http.MethodDelete, cookie: token, header: token, wantStatus: http.StatusOK},
+		{name: "delete without token", method: http.MethodDelete, cookie: token, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBody, gotToken string
+			h := CSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				gotBody, gotToken = string(b), CSRFToken(r)
+			}))
+			r := httptest.NewRequest(tt.method, "/api/test"+tt.query, strings.NewReader(tt.body))
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				r.Header.Set(CSRFHeader, tt.header)
+			}
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+			setCookie := w.Header().Get("Set-Cookie") != ""
+			if setCookie != tt.wantCookie {
+				t.Errorf("Set-Cookie sent = %v, want %v", setCookie, tt.wantCookie)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			if !validCSRFToken(gotToken) {
+				t.Errorf("CSRFToken = %q, want a valid token", gotToken)
+			}
+			if gotBody != tt.body {
+				t.Errorf("handler body %q, want the original body %q", gotBody, tt.body)
+			}
+		})
+	}
+}
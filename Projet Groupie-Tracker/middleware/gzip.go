@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// gzipMinSize est la taille en dessous de laquelle une réponse n'est pas
+// compressée : le gain serait annulé par l'en-tête gzip.
+const gzipMinSize = 512
+
+// compressibleTypes liste les types MIME compressés par Gzip.
+var compressibleTypes = map[string]bool{
+	"text/html":              true,
+	"application/json":       true,
+	"text/css":               true,
+	"text/plain":             true,
+	"text/javascript":        true,
+	"application/javascript": true,
+}
+
+var gzipPool = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+
+// Gzip compresse les réponses HTML, JSON et CSS quand le client annonce
+// `Accept-Encoding: gzip`. Les réponses déjà encodées, sans corps (HEAD,
+// 204, 304) ou trop courtes sont transmises telles quelles.
+func Gzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipWriter{ResponseWriter: w}
+		next.ServeHTTP(gw, r)
+		// Pas de defer : en cas de panic, le corps en attente est abandonné
+		// pour que Recoverer puisse encore envoyer sa page d'erreur.
+		gw.close()
+	})
+}
+
+// acceptsGzip indique si l'en-tête `Accept-Encoding` autorise gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(coding) != "gzip" {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// gzipWriter retarde l'envoi des en-têtes jusqu'à connaître assez de corps
+// pour décider de compresser ou non la réponse.
+type gzipWriter struct {
+	http.ResponseWriter
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	// Les réponses informatives (1xx) ne sont pas mises en attente.
+	if status < 200 {
+		w.status = 0
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		if w.gz != nil {
+			return w.gz.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= gzipMinSize {
+		if err := w.flushBuffer(w.shouldCompress()); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// shouldCompress décide à partir des en-têtes et du début du corps.
+func (w *gzipWriter) shouldCompress() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || len(w.buf) < gzipMinSize {
+		return false
+	}
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		ct = http.DetectContentType(w.buf)
+		h.Set("Content-Type", ct)
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	return err == nil && compressibleTypes[mt]
+}
+
+// decide envoie les en-têtes, avec ou sans compression.
+func (w *gzipWriter) decide(compress bool) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	if compress {
+		h := w.Header()
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		gz := gzipPool.Get().(*gzip.Writer)
+		gz.Reset(w.ResponseWriter)
+		w.gz = gz
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// flushBuffer prend la décision puis écrit le corps mis en attente.
+func (w *gzipWriter) flushBuffer(compress bool) error {
+	w.decide(compress)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.gz != nil {
+		_, err = w.gz.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close termine la réponse : corps court envoyé sans compression, ou flux
+// gzip fermé et rendu au pool.
+func (w *gzipWriter) close() {
+	if w.status == 0 && len(w.buf) == 0 {
+		// Le handler n'a rien écrit : net/http enverra un 200 vide.
+		return
+	}
+	if !w.decided {
+		w.flushBuffer(w.shouldCompress())
+	}
+	if w.gz != nil {
+		w.gz.Close()
+		gzipPool.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+// Flush envoie immédiatement ce qui a été écrit (réponses en flux) : la
+// décision de compression est prise avec le corps disponible.
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		w.flushBuffer(w.shouldCompress())
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack permet aux handlers de reprendre la connexion (WebSocket).
+func (w *gzipWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("middleware: hijack not supported")
+}
+
+// Unwrap expose le writer d'origine à `http.ResponseController`.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"groupie_tracker/metrics"
+)
+
+var (
+	httpRequests = metrics.NewCounterVec("http_requests_total",
+		"HTTP requests processed, by method, route and status code.", "method", "route", "code")
+	httpDuration = metrics.NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latencies in seconds, by route.", nil, "route")
+)
+
+// Metrics compte les requêtes et mesure leur durée pour `/metrics`.
+// Les séries sont indexées par le motif de route de `mux` qui traite la
+// requête (ex: "/club/{id}") plutôt que par le chemin, pour garder un
+// nombre de séries borné. Placé juste après Logger, il compte aussi les
+// erreurs 500 produites par Recoverer et les refus CSRF.
+func Metrics(mux *http.ServeMux) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			_, route := mux.Handler(r)
+			if route == "" {
+				route = "unmatched"
+			}
+			sw := &statusWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+			status := sw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			httpRequests.Inc(metricMethod(r.Method), route, strconv.Itoa(status))
+			httpDuration.Observe(time.Since(start).Seconds(), route)
+		})
+	}
+}
+
+// metricMethod renvoie la méthode de la requête pour les métriques, ou
+// "other" pour une méthode non standard : le client choisit la méthode,
+// qui ne doit pas pouvoir créer un nombre illimité de séries.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
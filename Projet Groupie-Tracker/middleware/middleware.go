@@ -0,0 +1,100 @@
+// Package middleware regroupe les comportements transverses appliqués à
+// toutes les requêtes HTTP : journalisation, métriques, récupération des
+// panics, compression gzip et protection CSRF.
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrorRenderer écrit une réponse d'erreur `status` : `publicMsg` (vide
+// pour le message générique du statut) est montré au client, `err` n'est
+// que journalisé. Voir `controller.RenderError`.
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, status int, publicMsg string, err error)
+
+// renderError rend les erreurs des middlewares (panic, jeton CSRF
+// invalide) ; `plainError` tant que SetErrorRenderer n'a pas été appelée.
+var renderError ErrorRenderer = plainError
+
+// SetErrorRenderer définit le rendu des erreurs des middlewares, pour
+// qu'elles aient les mêmes pages que celles des handlers.
+func SetErrorRenderer(fn ErrorRenderer) {
+	renderError = fn
+}
+
+// plainError écrit l'erreur en JSON pour `/api/*`, en texte sinon.
+func plainError(w http.ResponseWriter, r *http.Request, status int, publicMsg string, _ error) {
+	if publicMsg == "" {
+		publicMsg = strings.ToLower(http.StatusText(status))
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": publicMsg, "status": status})
+		return
+	}
+	http.Error(w, publicMsg, status)
+}
+
+// Middleware enveloppe un handler pour lui ajouter un comportement.
+type Middleware func(http.Handler) http.Handler
+
+// Chain applique les middlewares à `h` : le premier de la liste est le plus
+// externe, c'est-à-dire le premier à voir la requête et le dernier à voir
+// la réponse. Chain(h, A, B) équivaut à A(B(h)).
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// statusWriter mémorise le statut et le nombre d'octets écrits par le
+// handler, pour la journalisation et pour savoir si la réponse a commencé.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Flush transmet le flush au writer sous-jacent s'il le permet, afin que
+// les réponses en flux continuent de fonctionner à travers la chaîne.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack permet aux handlers de reprendre la connexion (WebSocket).
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("middleware: hijack not supported")
+}
+
+// Unwrap expose le writer d'origine à `http.ResponseController`.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
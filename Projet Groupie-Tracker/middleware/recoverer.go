@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recoverer intercepte les panics des handlers : la pile d'appels est
+// journalisée et le client reçoit une erreur 500 (JSON pour `/api/*`,
+// page d'erreur sinon, voir SetErrorRenderer) au lieu d'une connexion
+// coupée. Si la réponse avait déjà commencé, seul le journal est écrit.
+// `http.ErrAbortHandler` est relancée pour garder son comportement standard.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			log.Printf("panic serving %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack())
+			if sw.status != 0 {
+				return
+			}
+			h := w.Header()
+			h.Del("Content-Encoding")
+			h.Del("Content-Length")
+			h.Del("Content-Type")
+			h.Set("Cache-Control", "no-store")
+			renderError(w, r, http.StatusInternalServerError, "", nil)
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
@@ -1,9 +1,18 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 )
 
 // Club represents minimal club information used by the templates.
@@ -16,6 +25,150 @@ type Club struct {
 	Founded   int    `json:"founded,omitempty"`
 	Venue     string `json:"venue,omitempty"`
 	CrestURL  string `json:"crestUrl,omitempty"`
+	// Informations complémentaires fournies par football-data.org.
+	Country     string `json:"country,omitempty"`
+	City        string `json:"city,omitempty"`
+	Address     string `json:"address,omitempty"`
+	Colors      string `json:"colors,omitempty"`      // ex: "Sky Blue / White"
+	Competition string `json:"competition,omitempty"` // ex: "Premier League"
+}
+
+// ErrClubNotFound est renvoyée lorsqu'aucun club ne correspond à l'ID demandé.
+var ErrClubNotFound = errors.New("club not found")
+
+// ErrClubExists est renvoyée à la création d'un club dont l'ID est déjà pris.
+var ErrClubExists = errors.New("club already exists")
+
+// tlaPattern est le format d'un code TLA : 2 à 4 lettres majuscules.
+var tlaPattern = regexp.MustCompile(`^[A-Z]{2,4}$`)
+
+// FieldError est une erreur de validation d'un champ : la clé de
+// traduction de son message (voir le paquet i18n) et ses paramètres.
+// Les modèles ne dépendent pas de la langue de la requête : c'est au
+// contrôleur de traduire le message.
+type FieldError struct {
+	Key  string
+	Args []interface{}
+}
+
+// fieldError construit une FieldError.
+func fieldError(key string, args ...interface{}) FieldError {
+	return FieldError{Key: key, Args: args}
+}
+
+// Validate vérifie les champs d'un club avant son enregistrement et
+// renvoie les erreurs par nom de champ JSON (map vide si le club est
+// valide). Le nom, le nom court et le TLA sont obligatoires.
+func (c Club) Validate() map[string]FieldError {
+	errs := map[string]FieldError{}
+	if c.ID < 0 {
+		errs["id"] = fieldError("admin.error.id")
+	}
+	if strings.TrimSpace(c.Name) == "" {
+		errs["name"] = fieldError("admin.error.name_required")
+	} else if len(c.Name) > 100 {
+		errs["name"] = fieldError("admin.error.name_length", 100)
+	}
+	if strings.TrimSpace(c.ShortName) == "" {
+		errs["shortName"] = fieldError("admin.error.short_name_required")
+	} else if len(c.ShortName) > 50 {
+		errs["shortName"] = fieldError("admin.error.short_name_length", 50)
+	}
+	if !tlaPattern.MatchString(c.TLA) {
+		errs["tla"] = fieldError("admin.error.tla")
+	}
+	if c.Founded != 0 && (c.Founded < 1800 || c.Founded > time.Now().Year()) {
+		errs["founded"] = fieldError("admin.error.founded_range", 1800, time.Now().Year())
+	}
+	if c.Website != "" {
+		if u, err := url.Parse(c.Website); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs["website"] = fieldError("admin.error.website")
+		}
+	}
+	if len(c.Venue) > 100 {
+		errs["venue"] = fieldError("admin.error.venue_length", 100)
+	}
+	for field, value := range map[string]string{
+		"country":     c.Country,
+		"city":        c.City,
+		"colors":      c.Colors,
+		"competition": c.Competition,
+	} {
+		if len(value) > 100 {
+			errs[field] = fieldError("admin.error.field_length", 100)
+		}
+	}
+	if len(c.Address) > 200 {
+		errs["address"] = fieldError("admin.error.address_length", 200)
+	}
+	return errs
+}
+
+// Countries renvoie les pays distincts de `clubs`, triés, sans valeur vide.
+func Countries(clubs []Club) []string {
+	seen := map[string]bool{}
+	countries := []string{}
+	for _, c := range clubs {
+		if c.Country != "" && !seen[c.Country] {
+			seen[c.Country] = true
+			countries = append(countries, c.Country)
+		}
+	}
+	sort.Strings(countries)
+	return countries
+}
+
+// GetClubByID renvoie le club portant l'identifiant `id` dans `clubs`,
+// ou `ErrClubNotFound` s'il n'existe pas.
+func GetClubByID(clubs []Club, id int) (Club, error) {
+	for _, club := range clubs {
+		if club.ID == id {
+			return club, nil
+		}
+	}
+	return Club{}, ErrClubNotFound
+}
+
+// IsClubField indique si `name` est le nom JSON d'un champ de `Club`.
+// Utilisé pour valider les listes de champs demandées par l'API.
+func IsClubField(name string) bool {
+	if name == "" {
+		return false
+	}
+	t := reflect.TypeOf(Club{})
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
+
+// dataFileCandidates renvoie les chemins essayés pour trouver le fichier
+// de données `path`, afin que le chargement fonctionne quel que soit le
+// répertoire de travail.
+func dataFileCandidates(path string) []string {
+	return []string{
+		path,
+		"./" + path,
+		"../" + path,
+		"../../" + path,
+	}
+}
+
+// FindDataFile renvoie le premier chemin existant parmi les candidats
+// pour `path`, ou une erreur listant les chemins essayés.
+func FindDataFile(path string) (string, error) {
+	candidates := dataFileCandidates(path)
+	var err error
+	for _, p := range candidates {
+		var fi os.FileInfo
+		if fi, err = os.Stat(p); err == nil && !fi.IsDir() {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found; tried: %v; last error: %w", path, candidates, err)
 }
 
 // LoadClubsFromFile lit un fichier JSON contenant un tableau de clubs et
@@ -24,13 +177,7 @@ type Club struct {
 // plusieurs chemins relatifs avant de renvoyer une erreur.
 func LoadClubsFromFile(path string) ([]Club, error) {
 	// Try a set of candidate paths so loading works regardless of working dir
-	candidates := []string{
-		path,
-		"./" + path,
-		"../" + path,
-		"../../" + path,
-		"data/clubs.json",
-	}
+	candidates := append(dataFileCandidates(path), "data/clubs.json")
 	var b []byte
 	var err error
 	var found string
@@ -50,3 +197,41 @@ func LoadClubsFromFile(path string) ([]Club, error) {
 	}
 	return clubs, nil
 }
+
+// SaveClubsToFile écrit `clubs` dans le fichier JSON `path`, un club par
+// ligne comme dans `data/clubs.json`. Le fichier est d'abord écrit à côté
+// puis renommé, pour qu'un lecteur ne voie jamais un fichier à moitié écrit.
+func SaveClubsToFile(path string, clubs []Club) error {
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i, club := range clubs {
+		b, err := json.Marshal(club)
+		if err != nil {
+			return err
+		}
+		buf.WriteString("  ")
+		buf.Write(b)
+		if i < len(clubs)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("]\n")
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".clubs-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
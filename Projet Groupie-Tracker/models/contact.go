@@ -0,0 +1,71 @@
+package models
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limites de longueur des champs du formulaire de contact (en caractères).
+const (
+	ContactNameMax    = 100
+	ContactEmailMax   = 254
+	ContactMessageMax = 5000
+)
+
+// ContactMessage est un message envoyé avec le formulaire `/contact`.
+type ContactMessage struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewContactMessage construit un message à partir des champs du
+// formulaire, débarrassés des espaces superflus.
+func NewContactMessage(name, email, message string) ContactMessage {
+	return ContactMessage{
+		Name:    strings.TrimSpace(name),
+		Email:   strings.TrimSpace(email),
+		Message: strings.TrimSpace(message),
+	}
+}
+
+// Validate vérifie les champs du message et renvoie les erreurs par nom
+// de champ (map vide si le message est valide). Le nom, l'e-mail et le
+// message sont obligatoires ; le nom tient sur une ligne.
+func (m ContactMessage) Validate() map[string]FieldError {
+	errs := map[string]FieldError{}
+	if m.Name == "" {
+		errs["name"] = fieldError("contact.error.name_required")
+	} else if utf8.RuneCountInString(m.Name) > ContactNameMax {
+		errs["name"] = fieldError("contact.error.name_length", ContactNameMax)
+	} else if strings.IndexFunc(m.Name, unicode.IsControl) >= 0 {
+		errs["name"] = fieldError("contact.error.name_line")
+	}
+	if m.Email == "" {
+		errs["email"] = fieldError("contact.error.email_required")
+	} else if len(m.Email) > ContactEmailMax || !validEmail(m.Email) {
+		errs["email"] = fieldError("contact.error.email_invalid")
+	}
+	if m.Message == "" {
+		errs["message"] = fieldError("contact.error.message_required")
+	} else if utf8.RuneCountInString(m.Message) > ContactMessageMax {
+		errs["message"] = fieldError("contact.error.message_length", ContactMessageMax)
+	}
+	return errs
+}
+
+// validEmail accepte une adresse simple ("nom@domaine.tld"), sans nom
+// affiché ni caractères de contrôle.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return false
+	}
+	at := strings.LastIndex(email, "@")
+	return at > 0 && strings.Contains(email[at+1:], ".")
+}
@@ -2,7 +2,10 @@ package models
 
 import (
 	"encoding/json"
+	"net/url"
 	"os"
+	"regexp"
+	"strings"
 )
 
 // Collection represents the top-level structure of data.json (Postman collection).
@@ -20,20 +23,113 @@ type Info struct {
 	Schema    string `json:"schema"`
 }
 
+// Item est une requête de la collection ou, si `Item` n'est pas vide, un
+// dossier de requêtes.
 type Item struct {
 	Name                    string                 `json:"name"`
 	ID                      string                 `json:"id,omitempty"`
+	Description             string                 `json:"description,omitempty"`
+	Item                    []Item                 `json:"item,omitempty"`
 	ProtocolProfileBehavior map[string]interface{} `json:"protocolProfileBehavior,omitempty"`
 	Event                   []Event                `json:"event,omitempty"`
 	Request                 *Request               `json:"request,omitempty"`
 	Response                []interface{}          `json:"response,omitempty"`
 }
 
+// IsFolder indique si l'élément est un dossier.
+func (it Item) IsFolder() bool {
+	return it.Request == nil && len(it.Item) > 0
+}
+
 type Request struct {
-	Method string   `json:"method,omitempty"`
-	Header []Header `json:"header,omitempty"`
-	
-	URL json.RawMessage `json:"url,omitempty"`
+	Method      string   `json:"method,omitempty"`
+	Header      []Header `json:"header,omitempty"`
+	URL         URL      `json:"url"`
+	Description string   `json:"description,omitempty"`
+}
+
+// URL est l'URL d'une requête. Postman l'écrit soit sous forme de chaîne
+// (seul `Raw` est alors rempli), soit sous forme d'objet détaillé.
+type URL struct {
+	Raw   string       `json:"raw"`
+	Host  []string     `json:"host,omitempty"`
+	Path  []string     `json:"path,omitempty"`
+	Query []QueryParam `json:"query,omitempty"`
+}
+
+// UnmarshalJSON accepte les deux formes de l'URL Postman.
+func (u *URL) UnmarshalJSON(b []byte) error {
+	var raw string
+	if err := json.Unmarshal(b, &raw); err == nil {
+		*u = URL{Raw: raw}
+		return nil
+	}
+	type plain URL
+	return json.Unmarshal(b, (*plain)(u))
+}
+
+// Params renvoie les paramètres de requête de l'URL : ceux de `Query`,
+// ou à défaut ceux lus dans `Raw`, dans l'ordre d'origine.
+func (u URL) Params() []QueryParam {
+	if len(u.Query) > 0 {
+		return u.Query
+	}
+	_, rawQuery, ok := strings.Cut(u.Raw, "?")
+	if !ok {
+		return nil
+	}
+	var params []QueryParam
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(pair, "=")
+		k, _ = url.QueryUnescape(k)
+		v, _ = url.QueryUnescape(v)
+		params = append(params, QueryParam{Key: k, Value: v})
+	}
+	return params
+}
+
+// Resolve renvoie l'URL complète, sans ses paramètres de requête, en
+// remplaçant les variables `{{nom}}` par leur valeur dans `vars`. Les
+// doubles barres obliques dues à une variable terminée par "/" (ex:
+// "{{url}}/v4") sont supprimées.
+func (u URL) Resolve(vars map[string]string) string {
+	base, _, _ := strings.Cut(u.Raw, "?")
+	if base == "" {
+		base = strings.Join(u.Host, ".")
+		if len(u.Path) > 0 {
+			base += "/" + strings.Join(u.Path, "/")
+		}
+	}
+	base = ResolveVariables(base, vars)
+	scheme, rest, ok := strings.Cut(base, "://")
+	if !ok {
+		scheme, rest = "", base
+	}
+	for strings.Contains(rest, "//") {
+		rest = strings.ReplaceAll(rest, "//", "/")
+	}
+	if scheme == "" {
+		return rest
+	}
+	return scheme + "://" + rest
+}
+
+// variablePattern reconnaît une variable Postman `{{nom}}`.
+var variablePattern = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// ResolveVariables remplace dans `s` les variables `{{nom}}` définies dans
+// `vars` ; les variables inconnues sont laissées telles quelles.
+func ResolveVariables(s string, vars map[string]string) string {
+	return variablePattern.ReplaceAllStringFunc(s, func(m string) string {
+		name := variablePattern.FindStringSubmatch(m)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return m
+	})
 }
 
 type Header struct {
@@ -44,8 +140,9 @@ type Header struct {
 }
 
 type QueryParam struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
 }
 
 type Event struct {
@@ -76,10 +173,26 @@ type Variable struct {
 	Type  string `json:"type,omitempty"`
 }
 
+// Variables renvoie les variables de la collection (`{{nom}}`), indexées
+// par nom.
+func (c *Collection) Variables() map[string]string {
+	vars := make(map[string]string, len(c.Variable))
+	for _, v := range c.Variable {
+		vars[v.Key] = v.Value
+	}
+	return vars
+}
+
 // LoadCollectionFromFile lit un fichier JSON et le désérialise dans une
 // structure `Collection`. Elle renvoie la collection et une erreur le cas échéant.
+// Comme pour les autres fichiers de données, plusieurs chemins relatifs
+// sont essayés (voir `FindDataFile`).
 func LoadCollectionFromFile(path string) (*Collection, error) {
-	b, err := os.ReadFile(path)
+	found, err := FindDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(found)
 	if err != nil {
 		return nil, err
 	}
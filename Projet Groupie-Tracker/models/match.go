@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// Statuts de match utilisés par football-data.org.
+const (
+	StatusScheduled = "SCHEDULED"
+	StatusTimed     = "TIMED"
+	StatusInPlay    = "IN_PLAY"
+	StatusPaused    = "PAUSED"
+	StatusFinished  = "FINISHED"
+	StatusPostponed = "POSTPONED"
+)
+
+// Match represents a fixture between two clubs. Scores are nil until
+// the match has been played.
+type Match struct {
+	ID          int       `json:"id"`
+	Competition string    `json:"competition"`
+	Matchday    int       `json:"matchday,omitempty"`
+	UtcDate     time.Time `json:"utcDate"`
+	Status      string    `json:"status"`
+	HomeTeamID  int       `json:"homeTeamId"`
+	AwayTeamID  int       `json:"awayTeamId"`
+	HomeScore   *int      `json:"homeScore,omitempty"`
+	AwayScore   *int      `json:"awayScore,omitempty"`
+}
+
+// Involves indique si le club `clubID` joue ce match.
+func (m Match) Involves(clubID int) bool {
+	return m.HomeTeamID == clubID || m.AwayTeamID == clubID
+}
+
+// Played indique si le match est terminé avec un score connu.
+func (m Match) Played() bool {
+	return m.Status == StatusFinished && m.HomeScore != nil && m.AwayScore != nil
+}
+
+// Upcoming indique si le match reste à jouer (programmé, avec ou sans horaire).
+func (m Match) Upcoming() bool {
+	return m.Status == StatusScheduled || m.Status == StatusTimed
+}
+
+// Live indique si le match est en cours (en jeu ou à la mi-temps).
+func (m Match) Live() bool {
+	return m.Status == StatusInPlay || m.Status == StatusPaused
+}
+
+// LoadMatchesFromFile lit un fichier JSON contenant un tableau de matchs
+// et renvoie les matchs triés par date de coup d'envoi.
+func LoadMatchesFromFile(path string) ([]Match, error) {
+	found, err := FindDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(found)
+	if err != nil {
+		return nil, err
+	}
+	var matches []Match
+	if err := json.Unmarshal(b, &matches); err != nil {
+		return nil, err
+	}
+	SortMatches(matches)
+	return matches, nil
+}
+
+// SortMatches trie les matchs par date de coup d'envoi puis par ID.
+func SortMatches(matches []Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if !matches[i].UtcDate.Equal(matches[j].UtcDate) {
+			return matches[i].UtcDate.Before(matches[j].UtcDate)
+		}
+		return matches[i].ID < matches[j].ID
+	})
+}
+
+// MatchStore garde en mémoire les matchs lus depuis `data/matches.json`
+// (ou l'API) avec les mêmes règles d'invalidation que `ClubStore`.
+type MatchStore struct {
+	cache *fileCache[Match]
+}
+
+// NewMatchStore crée un store de matchs adossé au fichier JSON `path`.
+func NewMatchStore(path string, ttl time.Duration) *MatchStore {
+	return &MatchStore{cache: newFileCache(path, ttl, LoadMatchesFromFile)}
+}
+
+// SetRemote définit une source distante prioritaire (repli sur le fichier).
+func (s *MatchStore) SetRemote(fetch func(ctx context.Context) ([]Match, error)) {
+	s.cache.setRemote(fetch)
+}
+
+// Invalidate vide le cache ; le prochain accès rechargera les données.
+func (s *MatchStore) Invalidate() {
+	s.cache.invalidate()
+}
+
+// All renvoie une copie de la liste des matchs, triée par date.
+func (s *MatchStore) All() ([]Match, error) {
+	return s.cache.all()
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Player represents a squad member of a club.
+type Player struct {
+	ID          int    `json:"id"`
+	ClubID      int    `json:"clubId"`
+	Name        string `json:"name"`
+	Position    string `json:"position,omitempty"`
+	Nationality string `json:"nationality,omitempty"`
+	DateOfBirth string `json:"dateOfBirth,omitempty"`
+	ShirtNumber int    `json:"shirtNumber,omitempty"`
+}
+
+// Age renvoie l'âge du joueur en années à partir de `DateOfBirth`
+// (format "2006-01-02"), ou 0 si la date est absente ou invalide.
+func (p Player) Age() int {
+	dob, err := time.Parse("2006-01-02", p.DateOfBirth)
+	if err != nil {
+		return 0
+	}
+	now := time.Now()
+	age := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// LoadPlayersFromFile lit un fichier JSON contenant un tableau de joueurs
+// et renvoie la slice de `Player` correspondante.
+func LoadPlayersFromFile(path string) ([]Player, error) {
+	found, err := FindDataFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(found)
+	if err != nil {
+		return nil, err
+	}
+	var players []Player
+	if err := json.Unmarshal(b, &players); err != nil {
+		return nil, err
+	}
+	return players, nil
+}
+
+// PlayerStore garde en mémoire les effectifs lus depuis `data/squads.json`
+// (ou l'API) avec les mêmes règles d'invalidation que `ClubStore`.
+type PlayerStore struct {
+	cache *fileCache[Player]
+}
+
+// NewPlayerStore crée un store de joueurs adossé au fichier JSON `path`.
+func NewPlayerStore(path string, ttl time.Duration) *PlayerStore {
+	return &PlayerStore{cache: newFileCache(path, ttl, LoadPlayersFromFile)}
+}
+
+// SetRemote définit une source distante prioritaire (repli sur le fichier).
+// Elle doit utiliser les mêmes IDs de clubs que la source des clubs.
+func (s *PlayerStore) SetRemote(fetch func(ctx context.Context) ([]Player, error)) {
+	s.cache.setRemote(fetch)
+}
+
+// Invalidate vide le cache ; le prochain accès rechargera les données.
+func (s *PlayerStore) Invalidate() {
+	s.cache.invalidate()
+}
+
+// All renvoie une copie de la liste de tous les joueurs.
+func (s *PlayerStore) All() ([]Player, error) {
+	return s.cache.all()
+}
+
+// ByClub renvoie l'effectif du club `clubID` (slice vide s'il n'est pas connu).
+func (s *PlayerStore) ByClub(clubID int) ([]Player, error) {
+	players, err := s.All()
+	if err != nil {
+		return nil, err
+	}
+	squad := []Player{}
+	for _, p := range players {
+		if p.ClubID == clubID {
+			squad = append(squad, p)
+		}
+	}
+	return squad, nil
+}
+
+// SquadLines sont les lignes d'un effectif, nommées comme les postes de
+// football-data.org.
+var SquadLines = []string{"Goalkeeper", "Defence", "Midfield", "Offence"}
+
+// Count associe un libellé (nationalité, poste) à un nombre de joueurs.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// SquadStats résume un effectif pour la comparaison d'effectifs.
+type SquadStats struct {
+	Size int `json:"size"`
+	// AverageAge est l'âge moyen (arrondi au dixième) des joueurs dont la
+	// date de naissance est connue, 0 s'il n'y en a aucun.
+	AverageAge    float64 `json:"averageAge"`
+	Nationalities []Count `json:"nationalities"`
+	// Positions compte les joueurs de chaque ligne de SquadLines (même
+	// vide), puis des autres postes renseignés.
+	Positions []Count `json:"positions"`
+	// MissingLines liste les lignes de SquadLines sans aucun joueur.
+	MissingLines []string `json:"missingLines,omitempty"`
+}
+
+// ComputeSquadStats calcule l'âge moyen, la répartition par nationalité
+// (de la plus représentée à la moins représentée, puis par nom) et la
+// couverture des postes de l'effectif `players`.
+func ComputeSquadStats(players []Player) SquadStats {
+	stats := SquadStats{Size: len(players)}
+	ages, withAge := 0, 0
+	nationalities := map[string]int{}
+	positions := map[string]int{}
+	for _, p := range players {
+		if age := p.Age(); age > 0 {
+			ages += age
+			withAge++
+		}
+		if p.Nationality != "" {
+			nationalities[p.Nationality]++
+		}
+		if p.Position != "" {
+			positions[p.Position]++
+		}
+	}
+	if withAge > 0 {
+		stats.AverageAge = math.Round(float64(ages)/float64(withAge)*10) / 10
+	}
+
+	stats.Nationalities = []Count{}
+	for name, n := range nationalities {
+		stats.Nationalities = append(stats.Nationalities, Count{name, n})
+	}
+	sort.Slice(stats.Nationalities, func(i, j int) bool {
+		a, b := stats.Nationalities[i], stats.Nationalities[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Name < b.Name
+	})
+
+	stats.Positions = []Count{}
+	for _, line := range SquadLines {
+		stats.Positions = append(stats.Positions, Count{line, positions[line]})
+		if positions[line] == 0 {
+			stats.MissingLines = append(stats.MissingLines, line)
+		}
+		delete(positions, line)
+	}
+	var others []string
+	for name := range positions {
+		others = append(others, name)
+	}
+	sort.Strings(others)
+	for _, name := range others {
+		stats.Positions = append(stats.Positions, Count{name, positions[name]})
+	}
+	return stats
+}
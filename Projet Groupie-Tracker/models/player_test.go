@@ -0,0 +1,40 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// born renvoie la date de naissance d'un joueur qui a eu `age` ans hier.
+func born(age int) string {
+	return time.Now().AddDate(-age, 0, -1).Format("2006-01-02")
+}
+
+func TestComputeSquadStats(t *testing.T) {
+	players := []Player{
+		{Name: "A", Position: "Goalkeeper", Nationality: "Spain", DateOfBirth: born(30)},
+		{Name: "B", Position: "Midfield", Nationality: "France", DateOfBirth: born(25)},
+		{Name: "C", Position: "Midfield", Nationality: "Spain", DateOfBirth: born(22)},
+		{Name: "D", Position: "Coach", Nationality: "Brazil"},
+	}
+	got := ComputeSquadStats(players)
+	want := SquadStats{
+		Size:          4,
+		AverageAge:    25.7,
+		Nationalities: []Count{{"Spain", 2}, {"Brazil", 1}, {"France", 1}},
+		Positions: []Count{
+			{"Goalkeeper", 1}, {"Defence", 0}, {"Midfield", 2}, {"Offence", 0},
+			{"Coach", 1},
+		},
+		MissingLines: []string{"Defence", "Offence"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ComputeSquadStats() = %+v\nwant %+v", got, want)
+	}
+
+	empty := ComputeSquadStats(nil)
+	if empty.Size != 0 || empty.AverageAge != 0 || len(empty.MissingLines) != len(SquadLines) {
+		t.Errorf("ComputeSquadStats(nil) = %+v", empty)
+	}
+}
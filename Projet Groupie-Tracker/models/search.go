@@ -0,0 +1,119 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// Types d'entités renvoyés par la recherche globale.
+const (
+	ResultClub   = "club"
+	ResultVenue  = "venue"
+	ResultPlayer = "player"
+)
+
+// SearchResult représente un résultat typé de la recherche globale.
+type SearchResult struct {
+	Type     string `json:"type"`
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Detail   string `json:"detail,omitempty"`
+	CrestURL string `json:"crestUrl,omitempty"`
+	ClubID   int    `json:"clubId,omitempty"` // club d'un joueur
+	Score    int    `json:"score"`
+}
+
+// matchScore note la correspondance entre `text` et la requête `q`
+// (déjà en minuscules) : 100 pour une égalité, 75 pour un préfixe,
+// 50 pour un début de mot, 25 pour une sous-chaîne, 0 sinon.
+func matchScore(text, q string) int {
+	t := strings.ToLower(text)
+	switch {
+	case t == "" || q == "":
+		return 0
+	case t == q:
+		return 100
+	case strings.HasPrefix(t, q):
+		return 75
+	case strings.Contains(t, " "+q):
+		return 50
+	case strings.Contains(t, q):
+		return 25
+	}
+	return 0
+}
+
+// SearchAll recherche `query` dans les clubs (nom, nom court, TLA), dans
+// les stades et dans les noms des joueurs, et renvoie une liste de
+// résultats typés triés par pertinence décroissante puis par nom. Un stade
+// n'apparaît qu'une fois même s'il est partagé par plusieurs clubs ; un
+// joueur dont le club ne figure pas dans `clubs` est ignoré.
+func SearchAll(clubs []Club, players []Player, query string) []SearchResult {
+	q := strings.ToLower(strings.TrimSpace(query))
+	results := []SearchResult{}
+	if q == "" {
+		return results
+	}
+
+	seenVenues := make(map[string]bool)
+	for _, club := range clubs {
+		score := matchScore(club.Name, q)
+		for _, s := range []int{matchScore(club.ShortName, q), matchScore(club.TLA, q)} {
+			if s > score {
+				score = s
+			}
+		}
+		if score > 0 {
+			results = append(results, SearchResult{
+				Type:     ResultClub,
+				ID:       club.ID,
+				Name:     club.Name,
+				Detail:   club.Venue,
+				CrestURL: club.CrestURL,
+				Score:    score,
+			})
+		}
+
+		venueKey := strings.ToLower(club.Venue)
+		if s := matchScore(club.Venue, q); s > 0 && !seenVenues[venueKey] {
+			seenVenues[venueKey] = true
+			results = append(results, SearchResult{
+				Type:   ResultVenue,
+				ID:     club.ID,
+				Name:   club.Venue,
+				Detail: club.Name,
+				Score:  s,
+			})
+		}
+	}
+
+	byID := make(map[int]Club, len(clubs))
+	for _, c := range clubs {
+		byID[c.ID] = c
+	}
+	for _, p := range players {
+		club, ok := byID[p.ClubID]
+		if !ok {
+			continue
+		}
+		if score := matchScore(p.Name, q); score > 0 {
+			results = append(results, SearchResult{
+				Type:     ResultPlayer,
+				ID:       p.ID,
+				Name:     p.Name,
+				Detail:   club.Name,
+				CrestURL: club.CrestURL,
+				ClubID:   club.ID,
+				Score:    score,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestSearchAll(t *testing.T) {
+	clubs := []Club{
+		{ID: 1, Name: "Manchester City FC", ShortName: "Man City", TLA: "MCI", Venue: "Etihad Stadium"},
+		{ID: 2, Name: "Arsenal FC", ShortName: "Arsenal", TLA: "ARS", Venue: "Emirates Stadium"},
+	}
+	players := []Player{
+		{ID: 10, ClubID: 1, Name: "Erling Haaland"},
+		{ID: 11, ClubID: 2, Name: "Bukayo Saka"},
+		{ID: 12, ClubID: 99, Name: "Erling Unknown"},
+	}
+	type result struct {
+		typ   string
+		id    int
+		score int
+	}
+	tests := []struct {
+		query string
+		want  []result
+	}{
+		{"haaland", []result{{ResultPlayer, 10, 50}}},
+		{"erling", []result{{ResultPlayer, 10, 75}}},
+		{"arsenal", []result{{ResultClub, 2, 100}}},
+		{"stadium", []result{{ResultVenue, 2, 50}, {ResultVenue, 1, 50}}},
+		{"man", []result{{ResultClub, 1, 75}}},
+		{" ", nil},
+	}
+	for _, tt := range tests {
+		got := SearchAll(clubs, players, tt.query)
+		if len(got) != len(tt.want) {
+			t.Errorf("SearchAll(%q) = %+v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i, w := range tt.want {
+			if g := got[i]; g.Type != w.typ || g.ID != w.id || g.Score != w.score {
+				t.Errorf("SearchAll(%q)[%d] = %s %d (score %d), want %s %d (score %d)",
+					tt.query, i, g.Type, g.ID, g.Score, w.typ, w.id, w.score)
+			}
+		}
+	}
+
+	got := SearchAll(clubs, players, "saka")
+	if len(got) != 1 || got[0].ClubID != 2 || got[0].Detail != "Arsenal FC" {
+		t.Errorf("player result = %+v, want club 2 (Arsenal FC)", got)
+	}
+}
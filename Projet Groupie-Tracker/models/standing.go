@@ -0,0 +1,175 @@
+package models
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StandingRow is one line of a league table.
+type StandingRow struct {
+	Position       int  `json:"position"`
+	Club           Club `json:"club"`
+	Played         int  `json:"played"`
+	Won            int  `json:"won"`
+	Drawn          int  `json:"drawn"`
+	Lost           int  `json:"lost"`
+	GoalsFor       int  `json:"goalsFor"`
+	GoalsAgainst   int  `json:"goalsAgainst"`
+	GoalDifference int  `json:"goalDifference"`
+	Points         int  `json:"points"`
+}
+
+// CompetitionStandings regroupe le classement d'une compétition (ou d'un
+// de ses groupes, pour les compétitions à phase de groupes de l'API).
+type CompetitionStandings struct {
+	Competition string        `json:"competition"`
+	Group       string        `json:"group,omitempty"`
+	Table       []StandingRow `json:"table"`
+}
+
+// ComputeStandings calcule le classement de chaque compétition à partir
+// des matchs terminés (3 points la victoire, 1 le nul). Tous les clubs
+// apparaissant dans un match de la compétition figurent au classement,
+// même sans match joué. Le départage se fait à la différence de buts,
+// puis aux buts marqués, puis par ordre alphabétique.
+// Les compétitions sont renvoyées triées par code.
+func ComputeStandings(matches []Match, clubs []Club) []CompetitionStandings {
+	byID := make(map[int]Club, len(clubs))
+	for _, c := range clubs {
+		byID[c.ID] = c
+	}
+
+	tables := make(map[string]map[int]*StandingRow)
+	row := func(comp string, clubID int) *StandingRow {
+		t, ok := tables[comp]
+		if !ok {
+			t = make(map[int]*StandingRow)
+			tables[comp] = t
+		}
+		r, ok := t[clubID]
+		if !ok {
+			club, found := byID[clubID]
+			if !found {
+				club = Club{ID: clubID}
+			}
+			r = &StandingRow{Club: club}
+			t[clubID] = r
+		}
+		return r
+	}
+
+	for _, m := range matches {
+		home := row(m.Competition, m.HomeTeamID)
+		away := row(m.Competition, m.AwayTeamID)
+		if !m.Played() {
+			continue
+		}
+		hs, as := *m.HomeScore, *m.AwayScore
+		home.Played++
+		away.Played++
+		home.GoalsFor += hs
+		home.GoalsAgainst += as
+		away.GoalsFor += as
+		away.GoalsAgainst += hs
+		switch {
+		case hs > as:
+			home.Won++
+			away.Lost++
+		case hs < as:
+			away.Won++
+			home.Lost++
+		default:
+			home.Drawn++
+			away.Drawn++
+		}
+	}
+
+	result := make([]CompetitionStandings, 0, len(tables))
+	for comp, t := range tables {
+		rows := make([]StandingRow, 0, len(t))
+		for _, r := range t {
+			r.GoalDifference = r.GoalsFor - r.GoalsAgainst
+			r.Points = 3*r.Won + r.Drawn
+			rows = append(rows, *r)
+		}
+		sort.Slice(rows, func(i, j int) bool {
+			a, b := rows[i], rows[j]
+			if a.Points != b.Points {
+				return a.Points > b.Points
+			}
+			if a.GoalDifference != b.GoalDifference {
+				return a.GoalDifference > b.GoalDifference
+			}
+			if a.GoalsFor != b.GoalsFor {
+				return a.GoalsFor > b.GoalsFor
+			}
+			return a.Club.Name < b.Club.Name
+		})
+		for i := range rows {
+			rows[i].Position = i + 1
+		}
+		result = append(result, CompetitionStandings{Competition: comp, Table: rows})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Competition < result[j].Competition })
+	return result
+}
+
+// StandingsStore garde en mémoire pendant `ttl` les classements lus depuis
+// une source distante (l'API football-data.org). Si la source échoue, le
+// dernier classement lu reste servi ; sans classement en cache, All
+// renvoie l'erreur et la source n'est réinterrogée qu'après `ttl`.
+// Un StandingsStore peut être utilisé par plusieurs goroutines en parallèle.
+type StandingsStore struct {
+	fetch func(ctx context.Context) ([]CompetitionStandings, error)
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	items    []CompetitionStandings
+	err      error
+	loadedAt time.Time
+}
+
+// NewStandingsStore crée un store de classements alimenté par `fetch`,
+// avec une durée de vie `ttl` (DefaultClubTTL si ttl <= 0).
+func NewStandingsStore(fetch func(ctx context.Context) ([]CompetitionStandings, error), ttl time.Duration) *StandingsStore {
+	if ttl <= 0 {
+		ttl = DefaultClubTTL
+	}
+	cacheNames.Lock()
+	cacheNames.list = append(cacheNames.list, "standings")
+	cacheNames.Unlock()
+	return &StandingsStore{fetch: fetch, ttl: ttl}
+}
+
+// All renvoie les classements en cache, relus depuis la source une fois
+// le TTL écoulé.
+func (s *StandingsStore) All() ([]CompetitionStandings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loadedAt.IsZero() && time.Since(s.loadedAt) <= s.ttl {
+		cacheRequests.Inc("standings", "hit")
+	} else {
+		cacheRequests.Inc("standings", "miss")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		items, err := s.fetch(ctx)
+		cancel()
+		s.loadedAt = time.Now()
+		if err == nil {
+			s.items, s.err = items, nil
+		} else {
+			dataLoadErrors.Inc("standings", "remote")
+			if s.items == nil {
+				s.err = err
+			} else {
+				log.Printf("standings reload failed, serving cached data: %v", err)
+			}
+		}
+	}
+	if s.items == nil {
+		return nil, s.err
+	}
+	return append([]CompetitionStandings(nil), s.items...), nil
+}
@@ -0,0 +1,280 @@
+package models
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"groupie_tracker/metrics"
+)
+
+// DefaultClubTTL est la durée de validité par défaut du cache de clubs.
+const DefaultClubTTL = 5 * time.Minute
+
+// Métriques des caches, indexées par le chemin du fichier (`cache`).
+var (
+	cacheRequests = metrics.NewCounterVec("groupie_cache_requests_total",
+		"Data cache lookups, by cache and result (hit or miss).", "cache", "result")
+	dataLoadErrors = metrics.NewCounterVec("groupie_data_load_errors_total",
+		"Failed data loads, by cache and source (remote or file).", "cache", "source")
+	// cacheNames liste les caches créés, pour le calcul du taux de succès.
+	cacheNames struct {
+		sync.Mutex
+		list []string
+	}
+)
+
+func init() {
+	metrics.NewGaugeFunc("groupie_cache_hit_ratio",
+		"Share of data cache lookups served without reloading, by cache.", "cache",
+		func() map[string]float64 {
+			cacheNames.Lock()
+			defer cacheNames.Unlock()
+			ratios := map[string]float64{}
+			for _, name := range cacheNames.list {
+				hits, misses := cacheRequests.Value(name, "hit"), cacheRequests.Value(name, "miss")
+				if hits+misses > 0 {
+					ratios[name] = hits / (hits + misses)
+				}
+			}
+			return ratios
+		})
+}
+
+// ClubFetcher récupère les clubs depuis une source distante (ex: l'API
+// football-data.org). Voir `ClubStore.SetRemote`.
+type ClubFetcher func(ctx context.Context) ([]Club, error)
+
+// fileCache garde en mémoire une liste d'éléments lue depuis un fichier
+// JSON (ou une source distante) pour éviter de la relire et de la
+// re-désérialiser à chaque requête.
+// Le cache est rechargé quand:
+//   - sa durée de vie (TTL) est écoulée ;
+//   - la date de modification du fichier local a changé (si les données
+//     proviennent du fichier) ;
+//   - `invalidate` a été appelée.
+//
+// Un fileCache peut être utilisé par plusieurs goroutines en parallèle.
+type fileCache[T any] struct {
+	mu       sync.RWMutex
+	path     string
+	ttl      time.Duration
+	load     func(path string) ([]T, error)
+	remote   func(ctx context.Context) ([]T, error)
+	onReload func(items []T)
+	items    []T
+	loadedAt time.Time
+	fromFile string
+	modTime  time.Time
+}
+
+func newFileCache[T any](path string, ttl time.Duration, load func(string) ([]T, error)) *fileCache[T] {
+	if ttl <= 0 {
+		ttl = DefaultClubTTL
+	}
+	cacheNames.Lock()
+	cacheNames.list = append(cacheNames.list, path)
+	cacheNames.Unlock()
+	return &fileCache[T]{path: path, ttl: ttl, load: load}
+}
+
+func (c *fileCache[T]) setRemote(fetch func(ctx context.Context) ([]T, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remote = fetch
+	c.items = nil
+}
+
+func (c *fileCache[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = nil
+}
+
+// all renvoie une copie des éléments, en rechargeant le cache si
+// nécessaire. En cas d'échec du rechargement alors qu'une version
+// précédente est en cache, celle-ci est conservée et renvoyée.
+// Chaque appel est compté comme un succès (`hit`) ou un rechargement
+// (`miss`) dans `groupie_cache_requests_total`.
+func (c *fileCache[T]) all() ([]T, error) {
+	c.mu.RLock()
+	if !c.staleLocked() {
+		items := append([]T(nil), c.items...)
+		c.mu.RUnlock()
+		cacheRequests.Inc(c.path, "hit")
+		return items, nil
+	}
+	c.mu.RUnlock()
+	cacheRequests.Inc(c.path, "miss")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Une autre goroutine a pu recharger le cache entre-temps.
+	if c.staleLocked() {
+		if err := c.reloadLocked(); err != nil {
+			if c.items == nil {
+				return nil, err
+			}
+			log.Printf("%s reload failed, serving cached data: %v", c.path, err)
+			// Réessayer seulement à l'expiration du TTL.
+			c.loadedAt, c.fromFile = time.Now(), ""
+		}
+	}
+	return append([]T(nil), c.items...), nil
+}
+
+// staleLocked indique si le cache doit être rechargé.
+// L'appelant doit détenir c.mu (en lecture ou en écriture).
+func (c *fileCache[T]) staleLocked() bool {
+	if c.items == nil || time.Since(c.loadedAt) > c.ttl {
+		return true
+	}
+	if c.fromFile != "" {
+		fi, err := os.Stat(c.fromFile)
+		if err != nil || !fi.ModTime().Equal(c.modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadLocked recharge les éléments depuis la source distante si elle
+// est définie, sinon (ou en cas d'échec) depuis le fichier local.
+// L'appelant doit détenir c.mu en écriture.
+func (c *fileCache[T]) reloadLocked() error {
+	if c.remote != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		items, err := c.remote(ctx)
+		cancel()
+		if err == nil {
+			c.items, c.loadedAt, c.fromFile = items, time.Now(), ""
+			c.reloaded()
+			return nil
+		}
+		dataLoadErrors.Inc(c.path, "remote")
+		log.Printf("remote source unavailable, falling back to %s: %v", c.path, err)
+	}
+
+	if err := c.loadFileLocked(); err != nil {
+		dataLoadErrors.Inc(c.path, "file")
+		return err
+	}
+	return nil
+}
+
+// loadFileLocked recharge les éléments depuis le fichier local.
+// L'appelant doit détenir c.mu en écriture.
+func (c *fileCache[T]) loadFileLocked() error {
+	found, err := FindDataFile(c.path)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(found)
+	if err != nil {
+		return err
+	}
+	items, err := c.load(found)
+	if err != nil {
+		return err
+	}
+	if items == nil {
+		items = []T{}
+	}
+	c.items, c.loadedAt = items, time.Now()
+	c.fromFile, c.modTime = found, fi.ModTime()
+	c.reloaded()
+	return nil
+}
+
+// filePath renvoie le fichier local du cache : celui chargé en dernier,
+// sinon le premier candidat existant pour `path`, sinon `path` lui-même.
+func (c *fileCache[T]) filePath() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.fromFile != "" {
+		return c.fromFile
+	}
+	if found, err := FindDataFile(c.path); err == nil {
+		return found
+	}
+	return c.path
+}
+
+// reloaded appelle `onReload` avec les nouveaux éléments, pour que les
+// structures dérivées (index) soient reconstruites une seule fois par
+// chargement. L'appelant doit détenir c.mu en écriture.
+func (c *fileCache[T]) reloaded() {
+	if c.onReload != nil {
+		c.onReload(c.items)
+	}
+}
+
+// ClubStore garde en mémoire la liste des clubs (voir `fileCache` pour
+// les règles d'invalidation). Un ClubStore peut être utilisé par
+// plusieurs goroutines en parallèle.
+type ClubStore struct {
+	cache *fileCache[Club]
+	index atomic.Pointer[SuggestIndex]
+}
+
+// NewClubStore crée un store adossé au fichier JSON `path` avec une durée
+// de vie `ttl` (DefaultClubTTL si ttl <= 0). Le chargement est paresseux :
+// le fichier n'est lu qu'au premier accès.
+func NewClubStore(path string, ttl time.Duration) *ClubStore {
+	s := &ClubStore{cache: newFileCache(path, ttl, LoadClubsFromFile)}
+	s.cache.onReload = func(clubs []Club) {
+		s.index.Store(NewSuggestIndex(clubs))
+	}
+	return s
+}
+
+// SetRemote définit une source distante prioritaire. Si elle échoue,
+// le store se rabat sur le fichier local.
+func (s *ClubStore) SetRemote(fetch ClubFetcher) {
+	s.cache.setRemote(fetch)
+}
+
+// Invalidate vide le cache ; le prochain accès rechargera les données.
+func (s *ClubStore) Invalidate() {
+	s.cache.invalidate()
+}
+
+// All renvoie une copie de la liste des clubs.
+func (s *ClubStore) All() ([]Club, error) {
+	return s.cache.all()
+}
+
+// ByID renvoie le club d'identifiant `id`, ou `ErrClubNotFound`.
+func (s *ClubStore) ByID(id int) (Club, error) {
+	clubs, err := s.All()
+	if err != nil {
+		return Club{}, err
+	}
+	return GetClubByID(clubs, id)
+}
+
+// Save remplace le contenu du fichier local par `clubs` (voir
+// SaveClubsToFile) puis vide le cache. Si une source distante est
+// définie, elle reste prioritaire à la lecture : le fichier ne sert
+// alors que de repli.
+func (s *ClubStore) Save(clubs []Club) error {
+	if err := SaveClubsToFile(s.cache.filePath(), clubs); err != nil {
+		return err
+	}
+	s.cache.invalidate()
+	return nil
+}
+
+// Suggest renvoie au plus `limit` suggestions d'autocomplétion pour la
+// saisie `query` (voir SuggestIndex.Suggest). L'index est reconstruit à
+// chaque rechargement des clubs et non à chaque appel.
+func (s *ClubStore) Suggest(query string, limit int) ([]Suggestion, error) {
+	// All recharge le cache (et donc l'index) s'il est périmé.
+	if _, err := s.cache.all(); err != nil {
+		return nil, err
+	}
+	return s.index.Load().Suggest(query, limit), nil
+}
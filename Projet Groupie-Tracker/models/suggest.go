@@ -0,0 +1,377 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Suggestion est un résultat allégé de l'autocomplétion.
+type Suggestion struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	CrestURL string `json:"crestUrl,omitempty"`
+	Score    int    `json:"score"`
+}
+
+// suggestEntry est un club indexé : ses libellés normalisés (nom, nom
+// court, TLA) et les mots qui les composent.
+type suggestEntry struct {
+	club   Club
+	labels []string
+	words  []string
+}
+
+// SuggestIndex est un index d'autocomplétion construit une fois pour une
+// liste de clubs. Il contient les libellés et les mots des clubs triés
+// (recherche des préfixes par dichotomie) et associe chaque trigramme des
+// mots aux clubs qui le contiennent : à chaque frappe, seuls les clubs
+// trouvés dans ces index sont notés, au lieu de parcourir tous les clubs.
+// Un SuggestIndex est en lecture seule et peut être partagé entre
+// goroutines.
+type SuggestIndex struct {
+	entries []suggestEntry
+	labels  []indexKey // libellés normalisés, triés
+	words   []indexKey // mots des libellés, triés
+	// trigrams associe un trigramme aux indices (croissants) des clubs
+	// dont un mot le contient.
+	trigrams map[string][]int
+}
+
+// indexKey associe un libellé ou un mot à l'indice de son club.
+type indexKey struct {
+	key   string
+	entry int
+}
+
+// accentReplacer retire les accents courants pour que « atletico »
+// trouve « Atlético ».
+var accentReplacer = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a", "á", "a", "ã", "a", "å", "a",
+	"ç", "c", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss",
+)
+
+// normalize met `s` en minuscules, sans accents ni ponctuation, avec des
+// espaces simples entre les mots.
+func normalize(s string) string {
+	s = accentReplacer.Replace(strings.ToLower(s))
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// trigrams renvoie les trigrammes d'un mot, encadré d'espaces pour que
+// le début et la fin du mot comptent.
+func trigrams(word string) []string {
+	r := []rune(" " + word + " ")
+	if len(r) < 3 {
+		return nil
+	}
+	out := make([]string, 0, len(r)-2)
+	for i := 0; i+3 <= len(r); i++ {
+		out = append(out, string(r[i:i+3]))
+	}
+	return out
+}
+
+// innerTrigrams renvoie les trigrammes d'un mot sans l'encadrer
+// d'espaces : ceux que contient tout mot dont il est une sous-chaîne.
+func innerTrigrams(word string) []string {
+	r := []rune(word)
+	var out []string
+	for i := 0; i+3 <= len(r); i++ {
+		out = append(out, string(r[i:i+3]))
+	}
+	return out
+}
+
+// NewSuggestIndex indexe les clubs `clubs` pour SuggestIndex.Suggest.
+func NewSuggestIndex(clubs []Club) *SuggestIndex {
+	idx := &SuggestIndex{
+		entries:  make([]suggestEntry, 0, len(clubs)),
+		trigrams: make(map[string][]int),
+	}
+	for _, club := range clubs {
+		e := suggestEntry{club: club}
+		seen := map[string]bool{}
+		for _, label := range []string{club.Name, club.ShortName, club.TLA} {
+			n := normalize(label)
+			if n == "" || seen[n] {
+				continue
+			}
+			seen[n] = true
+			e.labels = append(e.labels, n)
+			for _, w := range strings.Fields(n) {
+				if !seen[" "+w] {
+					seen[" "+w] = true
+					e.words = append(e.words, w)
+				}
+			}
+		}
+		i := len(idx.entries)
+		idx.entries = append(idx.entries, e)
+		for _, l := range e.labels {
+			idx.labels = append(idx.labels, indexKey{l, i})
+		}
+		grams := map[string]bool{}
+		for _, w := range e.words {
+			idx.words = append(idx.words, indexKey{w, i})
+			for _, g := range trigrams(w) {
+				if !grams[g] {
+					grams[g] = true
+					idx.trigrams[g] = append(idx.trigrams[g], i)
+				}
+			}
+		}
+	}
+	sortKeys(idx.labels)
+	sortKeys(idx.words)
+	return idx
+}
+
+func sortKeys(keys []indexKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].key != keys[j].key {
+			return keys[i].key < keys[j].key
+		}
+		return keys[i].entry < keys[j].entry
+	})
+}
+
+// withPrefix ajoute à `set` les clubs dont une clé de `keys` (triées)
+// commence par `prefix`.
+func withPrefix(keys []indexKey, prefix string, set map[int]bool) {
+	i := sort.Search(len(keys), func(i int) bool { return keys[i].key >= prefix })
+	for ; i < len(keys) && strings.HasPrefix(keys[i].key, prefix); i++ {
+		set[keys[i].entry] = true
+	}
+}
+
+// exactCandidates renvoie les clubs susceptibles d'obtenir un score de
+// exactScore : un libellé commence par `q`, chaque mot saisi commence un
+// mot du club, ou tous les trigrammes des mots saisis d'au moins trois
+// lettres figurent dans les mots du club (sous-chaîne).
+func (idx *SuggestIndex) exactCandidates(q string, qWords []string) map[int]bool {
+	candidates := map[int]bool{}
+	withPrefix(idx.labels, q, candidates)
+
+	var words map[int]bool
+	for _, qw := range qWords {
+		set := map[int]bool{}
+		withPrefix(idx.words, qw, set)
+		if words != nil {
+			for i := range words {
+				if !set[i] {
+					delete(words, i)
+				}
+			}
+		} else {
+			words = set
+		}
+	}
+	for i := range words {
+		candidates[i] = true
+	}
+
+	var grams []string
+	for _, qw := range qWords {
+		grams = append(grams, innerTrigrams(qw)...)
+	}
+	if len(grams) == 0 {
+		return candidates
+	}
+	// Les clubs de la liste la plus courte sont vérifiés sur les autres.
+	sort.Slice(grams, func(i, j int) bool { return len(idx.trigrams[grams[i]]) < len(idx.trigrams[grams[j]]) })
+	for _, i := range idx.trigrams[grams[0]] {
+		if candidates[i] {
+			continue
+		}
+		all := true
+		for _, g := range grams[1:] {
+			postings := idx.trigrams[g]
+			if k := sort.SearchInts(postings, i); k == len(postings) || postings[k] != i {
+				all = false
+				break
+			}
+		}
+		if all {
+			candidates[i] = true
+		}
+	}
+	return candidates
+}
+
+// maxTypos renvoie le nombre de fautes tolérées pour un mot saisi :
+// aucune en dessous de 4 lettres, une jusqu'à 6, deux au-delà.
+func maxTypos(word string) int {
+	switch n := utf8.RuneCountInString(word); {
+	case n < 4:
+		return 0
+	case n <= 6:
+		return 1
+	}
+	return 2
+}
+
+// Suggest renvoie au plus `limit` clubs correspondant à la saisie `query`,
+// du plus pertinent au moins pertinent :
+//   - 100 : un libellé (nom, nom court, TLA) commence par la saisie ;
+//   - 80  : chaque mot saisi commence un mot du club ;
+//   - 60  : la saisie apparaît dans un libellé ;
+//   - 50 à 30 : correspondance approchée, chaque mot saisi étant proche
+//     (distance de Levenshtein, sur le début du mot) d'un mot du club.
+//
+// La sous-chaîne n'est cherchée que pour des mots saisis d'au moins trois
+// lettres. À score égal, les clubs sont triés par nom.
+func (idx *SuggestIndex) Suggest(query string, limit int) []Suggestion {
+	q := normalize(query)
+	out := []Suggestion{}
+	if q == "" || limit <= 0 {
+		return out
+	}
+	qWords := strings.Fields(q)
+
+	scores := map[int]int{}
+	for i := range idx.exactCandidates(q, qWords) {
+		if s := exactScore(idx.entries[i], q, qWords); s > 0 {
+			scores[i] = s
+		}
+	}
+
+	// Correspondances approchées : seuls les clubs partageant au moins
+	// un trigramme avec la saisie sont examinés.
+	if len(scores) < limit {
+		candidates := map[int]bool{}
+		for _, w := range qWords {
+			for _, g := range trigrams(w) {
+				for _, i := range idx.trigrams[g] {
+					candidates[i] = true
+				}
+			}
+		}
+		for i := range candidates {
+			if _, ok := scores[i]; ok {
+				continue
+			}
+			if s := fuzzyScore(idx.entries[i], qWords); s > 0 {
+				scores[i] = s
+			}
+		}
+	}
+
+	for i, s := range scores {
+		c := idx.entries[i].club
+		out = append(out, Suggestion{ID: c.ID, Name: c.Name, CrestURL: c.CrestURL, Score: s})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Name < out[j].Name
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// exactScore note les correspondances sans faute (préfixe, mots, sous-chaîne).
+func exactScore(e suggestEntry, q string, qWords []string) int {
+	for _, l := range e.labels {
+		if strings.HasPrefix(l, q) {
+			return 100
+		}
+	}
+	if allWords(qWords, func(qw string) bool {
+		for _, w := range e.words {
+			if strings.HasPrefix(w, qw) {
+				return true
+			}
+		}
+		return false
+	}) {
+		return 80
+	}
+	for _, l := range e.labels {
+		if strings.Contains(l, q) {
+			return 60
+		}
+	}
+	return 0
+}
+
+// fuzzyScore note la correspondance approchée : chaque mot saisi doit être
+// à au plus `maxTypos` modifications d'un mot du club (ou de son début,
+// pour une saisie en cours). Le score baisse de 10 par faute.
+func fuzzyScore(e suggestEntry, qWords []string) int {
+	total := 0
+	ok := allWords(qWords, func(qw string) bool {
+		best := -1
+		for _, w := range e.words {
+			d := levenshtein(qw, w)
+			// La saisie peut être le début d'un mot plus long.
+			if rw := []rune(w); len(rw) > len([]rune(qw)) {
+				if p := levenshtein(qw, string(rw[:len([]rune(qw))])); p < d {
+					d = p
+				}
+			}
+			if d <= maxTypos(qw) && (best < 0 || d < best) {
+				best = d
+			}
+		}
+		if best < 0 {
+			return false
+		}
+		total += best
+		return true
+	})
+	if !ok {
+		return 0
+	}
+	score := 50 - 10*total
+	if score < 30 {
+		score = 30
+	}
+	return score
+}
+
+// allWords indique si `match` est vrai pour chacun des mots `words`.
+func allWords(words []string, match func(string) bool) bool {
+	for _, w := range words {
+		if !match(w) {
+			return false
+		}
+	}
+	return len(words) > 0
+}
+
+// levenshtein renvoie la distance d'édition (insertions, suppressions,
+// substitutions) entre `a` et `b`.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,51 @@
+package models
+
+import "testing"
+
+var suggestClubs = []Club{
+	{ID: 1, Name: "Arsenal FC", ShortName: "Arsenal", TLA: "ARS"},
+	{ID: 2, Name: "Manchester City FC", ShortName: "Man City", TLA: "MCI"},
+	{ID: 3, Name: "Manchester United FC", ShortName: "Man United", TLA: "MUN"},
+	{ID: 4, Name: "Club Atlético de Madrid", ShortName: "Atleti", TLA: "ATM"},
+	{ID: 5, Name: "Real Madrid CF", ShortName: "Real Madrid", TLA: "RMA"},
+	{ID: 6, Name: "Chelsea FC", ShortName: "Chelsea", TLA: "CHE"},
+}
+
+func TestSuggest(t *testing.T) {
+	idx := NewSuggestIndex(suggestClubs)
+	tests := []struct {
+		name   string
+		query  string
+		limit  int
+		want   []int // IDs attendus, dans l'ordre
+		scores []int
+	}{
+		{"label prefix", "ars", 10, []int{1}, []int{100}},
+		{"short name prefix", "man", 10, []int{2, 3}, []int{100, 100}},
+		{"word prefixes", "city man", 10, []int{2}, []int{80}},
+		{"substring", "chest", 10, []int{2, 3}, []int{60, 60}},
+		{"accents", "atletico", 10, []int{4}, []int{80}},
+		{"one typo", "arsenl", 10, []int{1}, []int{40}},
+		{"typo while typing", "manchezt", 10, []int{2, 3}, []int{40, 40}},
+		{"swapped letters", "chelsae", 10, []int{6}, []int{30}},
+		{"two typos", "manchestre united", 10, []int{3}, []int{30}},
+		{"too many typos", "arxyz", 10, []int{}, []int{}},
+		{"limit", "madrid", 1, []int{4}, []int{80}},
+		{"limit keeps best scores", "ma", 2, []int{2, 3}, []int{100, 100}},
+		{"empty query", "  ", 10, []int{}, []int{}},
+		{"zero limit", "ars", 0, []int{}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idx.Suggest(tt.query, tt.limit)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Suggest(%q, %d) = %+v, want IDs %v", tt.query, tt.limit, got, tt.want)
+			}
+			for i, s := range got {
+				if s.ID != tt.want[i] || s.Score != tt.scores[i] {
+					t.Errorf("result %d = %d (score %d), want %d (score %d)", i, s.ID, s.Score, tt.want[i], tt.scores[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Package ratelimit limite le nombre d'actions coûteuses ou exposées aux
+// abus (connexion, envoi du formulaire de contact, appels à l'API) par
+// clé : adresse IP, e-mail... Chaque clé dispose d'un seau de jetons qui
+// se remplit régulièrement ; une action consomme un jeton.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// maxKeys borne le nombre de clés suivies : au-delà, les seaux pleins
+// (clés inactives) sont oubliés, puis les plus anciens si besoin.
+const maxKeys = 10000
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter autorise `Burst` actions d'affilée par clé, puis une action
+// toutes les `Every`.
+type Limiter struct {
+	Burst int
+	Every time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time // remplaçable pour les tests
+}
+
+// New crée un limiteur de `n` actions par période `per` et par clé
+// (`n` d'affilée au plus).
+func New(n int, per time.Duration) *Limiter {
+	if n < 1 {
+		n = 1
+	}
+	return &Limiter{Burst: n, Every: per / time.Duration(n), buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Allow consomme un jeton de `key` et indique si l'action est autorisée.
+// Sinon, elle renvoie aussi le délai avant le prochain jeton.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxKeys {
+			l.evict(now)
+		}
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens, b.last = l.available(b, now), now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(l.Every))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// available renvoie les jetons de `b` à l'instant `now`, en comptant ceux
+// gagnés depuis sa dernière mise à jour, sans modifier le seau.
+func (l *Limiter) available(b *bucket, now time.Time) float64 {
+	if l.Every <= 0 {
+		return float64(l.Burst)
+	}
+	return min(b.tokens+float64(now.Sub(b.last))/float64(l.Every), float64(l.Burst))
+}
+
+// evict oublie les seaux redevenus pleins ; s'il n'y en a pas, le plus
+// anciennement utilisé. L'appelant tient le verrou.
+func (l *Limiter) evict(now time.Time) {
+	var oldest string
+	var oldestAt time.Time
+	for k, b := range l.buckets {
+		if l.available(b, now) >= float64(l.Burst) {
+			delete(l.buckets, k)
+			continue
+		}
+		if oldest == "" || b.last.Before(oldestAt) {
+			oldest, oldestAt = k, b.last
+		}
+	}
+	if len(l.buckets) >= maxKeys {
+		delete(l.buckets, oldest)
+	}
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// clock est une horloge manuelle pour le champ `now` du limiteur.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newLimiter crée un limiteur réglé sur l'horloge `c`.
+func newLimiter(n int, per time.Duration, c *clock) *Limiter {
+	l := New(n, per)
+	l.now = c.now
+	return l
+}
+
+func TestAllow(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+	l := newLimiter(3, 3*time.Minute, c)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("action %d denied within the burst", i+1)
+		}
+	}
+	ok, retry := l.Allow("a")
+	if ok || retry != time.Minute {
+		t.Fatalf("Allow after the burst = %v, %v; want false, 1m", ok, retry)
+	}
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("another key was limited")
+	}
+
+	c.advance(30 * time.Second)
+	if ok, retry := l.Allow("a"); ok || retry != 30*time.Second {
+		t.Errorf("Allow after 30s = %v, %v; want false, 30s", ok, retry)
+	}
+	c.advance(30 * time.Second)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("Allow after a full period denied")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("refill gave more than one token per period")
+	}
+}
+
+// fill occupe le limiteur avec des clés utilisées une fois à l'instant courant.
+func fill(l *Limiter, n int) {
+	for i := 0; i < n; i++ {
+		l.Allow(fmt.Sprintf("k%d", i))
+	}
+}
+
+func TestEvictFullBucketsFirst(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+	l := newLimiter(2, 2*time.Minute, c)
+	l.Allow("idle")
+	l.Allow("old")
+	l.Allow("old")
+	c.advance(89 * time.Second)
+	fill(l, maxKeys-2)
+
+	// "idle" est redevenu plein, "old" non : seul "idle" est oublié.
+	c.advance(time.Second)
+	l.Allow("new")
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("full bucket was kept")
+	}
+	if _, ok := l.buckets["old"]; !ok {
+		t.Error("bucket still in use was evicted")
+	}
+	if len(l.buckets) != maxKeys {
+		t.Errorf("%d buckets, want %d", len(l.buckets), maxKeys)
+	}
+}
+
+func TestEvictLeastRecentlyUsed(t *testing.T) {
+	c := &clock{t: time.Unix(0, 0)}
+	l := newLimiter(2, 2*time.Minute, c)
+	l.Allow("old")
+	l.Allow("old")
+	c.advance(time.Second)
+	fill(l, maxKeys-1)
+
+	c.advance(time.Second)
+	l.Allow("new")
+	if _, ok := l.buckets["old"]; ok {
+		t.Error("least recently used bucket was kept")
+	}
+	for i := 0; i < maxKeys-1; i++ {
+		if _, ok := l.buckets[fmt.Sprintf("k%d", i)]; !ok {
+			t.Fatalf("bucket k%d was evicted instead of the oldest one", i)
+		}
+	}
+	if _, ok := l.buckets["new"]; !ok {
+		t.Error("new key was not added")
+	}
+}
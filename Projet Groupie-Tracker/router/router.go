@@ -1,61 +1,333 @@
 package router
 
 import (
+	"context"
+	"errors"
+	groupietracker "groupie_tracker"
+	"groupie_tracker/auth"
+	"groupie_tracker/config"
 	"groupie_tracker/controller"
+	"groupie_tracker/crests"
+	"groupie_tracker/i18n"
+	"groupie_tracker/live"
+	"groupie_tracker/metrics"
+	"groupie_tracker/middleware"
+	"groupie_tracker/models"
+	"groupie_tracker/storage"
+	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
-// New crée et configure un *http.ServeMux pour l'application.
-// Elle enregistre les handlers pour les routes HTML et l'API,
-// et configure le serveur de fichiers statiques sous `/static/`.
-func New() *http.ServeMux {
+// closers mémorise les ressources ouvertes par New (bases SQLite) pour
+// que Close puisse les libérer à l'arrêt du serveur.
+var closers []io.Closer
+
+// stoppers mémorise les traitements de fond et les flux ouverts par New
+// (mises à jour en direct), arrêtés par Shutdown dès le début de l'arrêt.
+var (
+	stoppers     []io.Closer
+	shutdownOnce sync.Once
+)
+
+// New crée le handler HTTP de l'application à partir de `cfg` : elle
+// configure les handlers du paquet controller (templates, dépôts de
+// données, administration...), enregistre les routes des pages, de l'API
+// et des fichiers statiques, puis enveloppe le mux dans la chaîne de
+// `middlewares`. Les routes POST qui modifient des données sont
+// enveloppées par `controller.Idempotent`.
+// Un template invalide arrête le programme dès le démarrage. Les
+// traitements de fond lancés ici sont arrêtés par Shutdown, et les
+// ressources ouvertes libérées par Close.
+func New(cfg *config.Config) http.Handler {
 	mux := http.NewServeMux()
+	templateFS, staticFS := assets(cfg)
+	if err := controller.SetTemplates(templateFS, cfg.Dev); err != nil {
+		log.Fatalf("cannot parse templates: %v", err)
+	}
+
+	middleware.SetErrorRenderer(controller.RenderError)
+	controller.SetClubRepository(newClubRepository(cfg, cfg.DatabasePath))
+	controller.SetAdmin(controller.AdminConfig{
+		Emails:   cfg.AdminEmails,
+		User:     cfg.AdminUser,
+		Password: cfg.AdminPassword,
+		CrestDir: filepath.Join(cfg.StaticDir, "crests"),
+	})
+	controller.SetPlayerStore(newPlayerStore(cfg))
+	matchStore := newMatchStore(cfg)
+	controller.SetMatchStore(matchStore)
+	if api := cfg.APIClient(); api != nil {
+		controller.SetStandingsStore(models.NewStandingsStore(api.Standings, cfg.CacheTTL))
+	}
+	hub, poller := newLive(cfg, matchStore)
+	controller.SetLiveHub(hub)
+	controller.SetLivePoller(poller)
+	controller.SetHealth(healthConfig(cfg))
+	controller.SetExplorer(controller.ExplorerConfig{
+		CollectionFile: cfg.CollectionFile,
+		Variables:      map[string]string{"url": cfg.APIURL},
+		APIKey:         cfg.APIKey,
+	})
+	clubsFile := cfg.ClubsFile
+	if cfg.ClubsBackend == "sqlite" {
+		clubsFile = cfg.DatabasePath
+	}
+	controller.SetSEO(controller.SEOConfig{
+		BaseURL:     cfg.BaseURL,
+		ClubsFile:   clubsFile,
+		SquadsFile:  cfg.SquadsFile,
+		MatchesFile: cfg.MatchesFile,
+	})
+	controller.SetContactStore(newContactStore(cfg))
+	controller.SetCrests(crests.New(cfg.CrestCacheDir, int64(cfg.CrestCacheMB)<<20, staticFS))
+	if m := cfg.Mailer(); m != nil {
+		controller.SetContactNotifier(m)
+		log.Printf("contact messages will be sent to %s via %s", m.To, m.Addr)
+	}
 
-	mux.HandleFunc("/", controller.HomeWithFavorites)
+	mux.HandleFunc("/{$}", controller.HomeWithFavorites)
+	mux.HandleFunc("/club/{id}", controller.ClubDetail)
+	mux.HandleFunc("/club/{id}/players", controller.ClubPlayers)
+	mux.HandleFunc("/matches", controller.Matches)
+	mux.HandleFunc("/events", controller.Events)
+	mux.HandleFunc("/standings", controller.Standings)
 	mux.HandleFunc("/favorites", controller.Favorites)
+	mux.HandleFunc("/favorites/export", controller.FavoritesExport)
+	mux.HandleFunc("/favorites/import", controller.Idempotent(controller.FavoritesImport))
+	mux.HandleFunc("/favorites/share", controller.FavoritesShare)
+	mux.HandleFunc("/compare", controller.Compare)
+	mux.HandleFunc("/compare/squads", controller.CompareSquads)
+	mux.HandleFunc("/crests/{clubID}", controller.CrestImage)
 	mux.HandleFunc("/about", controller.About)
-	mux.HandleFunc("/contact", controller.Contact)
+	mux.HandleFunc("/contact", controller.Idempotent(controller.Contact))
+	mux.HandleFunc("/search", controller.Search)
+	mux.HandleFunc("/api-explorer", controller.APIExplorer)
+	mux.HandleFunc("/api-explorer/try", controller.APIExplorerTry)
 	mux.HandleFunc("/api/clubs", controller.SearchAndFilter)
-	mux.HandleFunc("/add-favorite", controller.AddFavorite)
-	mux.HandleFunc("/remove-favorite", controller.RemoveFavorite)
-	mux.HandleFunc("/clear-favorites", controller.ClearFavorites)
-
-	// Serve static files (images, css) from data/static under /static/
-	staticDir := findStaticDir()
-	if staticDir == "" {
-		log.Printf("warning: data/static directory not found; static files won't be served")
-	} else {
-		fs := http.FileServer(http.Dir(staticDir))
-		mux.Handle("/static/", http.StripPrefix("/static/", fs))
-		log.Printf("serving static files from %s at /static/", staticDir)
+	mux.HandleFunc("/api/clubs/suggest", controller.SuggestAPI)
+	mux.HandleFunc("/api/search", controller.SearchAPI)
+	mux.HandleFunc("/api/players", controller.PlayersAPI)
+	mux.HandleFunc("/api/matches", controller.MatchesAPI)
+	mux.HandleFunc("/api/standings", controller.StandingsAPI)
+	mux.HandleFunc("/api/live", controller.LiveAPI)
+	mux.HandleFunc("/api/compare", controller.CompareAPI)
+	mux.HandleFunc("/api/compare/squads", controller.CompareSquadsAPI)
+	mux.HandleFunc("/api/favorites", controller.Idempotent(controller.FavoritesAPI))
+	mux.HandleFunc("/add-favorite", controller.Idempotent(controller.AddFavorite))
+	mux.HandleFunc("/remove-favorite", controller.Idempotent(controller.RemoveFavorite))
+	mux.HandleFunc("/clear-favorites", controller.Idempotent(controller.ClearFavorites))
+	mux.HandleFunc("/admin", controller.AdminOnly(controller.AdminClubs))
+	adminWrite := func(h http.HandlerFunc) http.HandlerFunc {
+		return controller.AdminOnly(controller.Idempotent(h))
 	}
+	mux.HandleFunc("/admin/clubs/new", adminWrite(controller.AdminNewClub))
+	mux.HandleFunc("/admin/clubs/{id}", adminWrite(controller.AdminEditClub))
+	mux.HandleFunc("/admin/clubs/{id}/delete", adminWrite(controller.AdminDeleteClub))
+	mux.HandleFunc("/admin/messages", controller.AdminOnly(controller.AdminMessages))
+	mux.HandleFunc("/login", controller.Login)
+	mux.HandleFunc("/register", controller.Register)
+	mux.HandleFunc("/logout", controller.Logout)
+	mux.HandleFunc("/sitemap.xml", controller.Sitemap)
+	mux.HandleFunc("/robots.txt", controller.Robots)
+	mux.HandleFunc("/healthz", controller.Healthz)
+	mux.HandleFunc("/readyz", controller.Readyz)
+	mux.HandleFunc("/metrics", controller.MetricsOnly(cfg.MetricsToken, metrics.Handler()))
+
+	// Fichiers statiques (images, css) servis sous /static/
+	mux.Handle("/static/", controller.StaticFiles(staticFS))
+	// Toute autre URL renvoie la page 404 (ou une erreur JSON sous /api/).
+	mux.HandleFunc("/", controller.NotFound)
+
+	return middleware.Chain(mux, middlewares(mux, cfg.DatabasePath)...)
+}
 
-	return mux
+// Shutdown arrête le poller des matchs et termine les flux `/events`,
+// qui sinon retarderaient l'arrêt du serveur jusqu'à `ShutdownTimeout`.
+// `main` l'enregistre avec `http.Server.RegisterOnShutdown`.
+func Shutdown() {
+	shutdownOnce.Do(func() {
+		for _, s := range stoppers {
+			s.Close()
+		}
+	})
+}
+
+// Close libère les ressources ouvertes par New (après Shutdown si elle
+// n'a pas déjà été appelée). Elle est appelée par `main` une fois le
+// serveur arrêté et les requêtes en cours terminées.
+func Close() error {
+	Shutdown()
+	var errs []error
+	for _, c := range closers {
+		errs = append(errs, c.Close())
+	}
+	closers = nil
+	return errors.Join(errs...)
 }
 
-// findStaticDir recherche le répertoire `data/static` en remontant
-// l'arborescence à partir du répertoire de travail courant (jusqu'à 6 niveaux).
-// Elle retourne le chemin trouvé ou une chaîne vide si aucun répertoire n'a été trouvé.
-func findStaticDir() string {
-	wd, err := os.Getwd()
+// middlewares renvoie la chaîne appliquée à toutes les requêtes, de la
+// plus externe à la plus interne. Logger et Metrics sont en tête pour
+// journaliser et compter aussi les erreurs 500 produites par Recoverer ;
+// Metrics utilise `mux` pour nommer la route de chaque requête.
+func middlewares(mux *http.ServeMux, dbPath string) []middleware.Middleware {
+	chain := []middleware.Middleware{
+		middleware.Logger,
+		middleware.Metrics(mux),
+		middleware.Recoverer,
+		middleware.Gzip,
+		i18n.Middleware,
+		middleware.CSRF,
+	}
+	store, err := auth.Open(dbPath)
 	if err != nil {
-		return ""
-	}
-	// search up to 6 levels
-	cur := wd
-	for i := 0; i < 6; i++ {
-		candidate := filepath.Join(cur, "data", "static")
-		if fi, err := os.Stat(candidate); err == nil && fi.IsDir() {
-			return candidate
+		log.Printf("warning: accounts disabled, cannot open database: %v", err)
+		return chain
+	}
+	closers = append(closers, store)
+	controller.SetAuthStore(store)
+	return append(chain, func(next http.Handler) http.Handler {
+		return auth.Middleware(store, next)
+	})
+}
+
+// assets renvoie les systèmes de fichiers des templates et des fichiers
+// statiques : ceux embarqués dans le binaire, ou en mode développement
+// les répertoires du disque, relus à chaque requête. Hors mode dev, les
+// fichiers présents sur le disque (blasons envoyés depuis `/admin`)
+// restent prioritaires sur les fichiers statiques embarqués.
+func assets(cfg *config.Config) (templates, static fs.FS) {
+	if !cfg.Dev {
+		static := overlayFS{upper: os.DirFS(cfg.StaticDir), lower: groupietracker.Static}
+		return groupietracker.Templates, static
+	}
+	log.Printf("dev mode: serving templates from %s and static files from %s",
+		cfg.TemplateDir, cfg.StaticDir)
+	return os.DirFS(cfg.TemplateDir), os.DirFS(cfg.StaticDir)
+}
+
+// healthConfig renvoie les dépendances vérifiées par `/healthz` : les
+// fichiers de données et, si une clé est configurée, l'API football-data.org.
+func healthConfig(cfg *config.Config) controller.HealthConfig {
+	hc := controller.HealthConfig{DataFiles: map[string]string{
+		"clubs":   cfg.ClubsFile,
+		"squads":  cfg.SquadsFile,
+		"matches": cfg.MatchesFile,
+	}}
+	if api := cfg.APIClient(); api != nil {
+		hc.PingAPI = func(ctx context.Context) error {
+			_, err := api.Competitions(ctx)
+			return err
+		}
+	}
+	return hc
+}
+
+// newClubStore construit le store de clubs partagé par les handlers.
+// Si une clé football-data.org est configurée, l'API devient la source
+// principale et le fichier de clubs la source de repli.
+func newClubStore(cfg *config.Config) *models.ClubStore {
+	store := models.NewClubStore(cfg.ClubsFile, cfg.CacheTTL)
+	if api := cfg.APIClient(); api != nil {
+		store.SetRemote(api.Teams)
+		log.Printf("loading clubs from football-data.org (competition %s)", api.Competition)
+	}
+	return store
+}
+
+// newPlayerStore construit le store des effectifs. Quand les clubs
+// viennent de l'API football-data.org (voir newClubStore), leurs IDs ne
+// sont pas ceux du fichier d'effectifs : les effectifs sont alors lus
+// depuis l'API aussi.
+func newPlayerStore(cfg *config.Config) *models.PlayerStore {
+	store := models.NewPlayerStore(cfg.SquadsFile, cfg.CacheTTL)
+	if api := cfg.APIClient(); api != nil && cfg.ClubsBackend != "sqlite" {
+		store.SetRemote(api.Squads)
+	}
+	return store
+}
+
+// newClubRepository choisit le dépôt de clubs selon `cfg.ClubsBackend` :
+// "sqlite" utilise la base `dbPath` (remplie avec la sous-commande
+// `import-clubs`), toute autre valeur le fichier JSON via `newClubStore`.
+// Si la base ne peut pas être ouverte, le fichier JSON est utilisé.
+func newClubRepository(cfg *config.Config, dbPath string) storage.ClubRepository {
+	if cfg.ClubsBackend == "sqlite" {
+		repo, err := storage.OpenSQLite(dbPath)
+		if err == nil {
+			if n, err := repo.Count(); err == nil && n == 0 {
+				log.Printf("warning: no clubs in %s; run `go run ./main import-clubs data/clubs.json`", dbPath)
+			}
+			log.Printf("loading clubs from SQLite database %s", dbPath)
+			closers = append(closers, repo)
+			return repo
 		}
-		parent := filepath.Dir(cur)
-		if parent == cur {
-			break
+		log.Printf("warning: cannot open clubs database, using JSON file: %v", err)
+	}
+	return storage.NewJSONRepository(newClubStore(cfg))
+}
+
+// newContactStore choisit le store des messages de contact selon
+// `cfg.ContactsBackend` : "sqlite" utilise la base `cfg.DatabasePath`,
+// toute autre valeur le fichier `cfg.ContactsFile`. Si la base ne peut
+// pas être ouverte, le fichier JSON est utilisé.
+func newContactStore(cfg *config.Config) storage.ContactStore {
+	if cfg.ContactsBackend == "sqlite" {
+		store, err := storage.OpenSQLiteContacts(cfg.DatabasePath)
+		if err == nil {
+			closers = append(closers, store)
+			return store
 		}
-		cur = parent
+		log.Printf("warning: cannot open contacts database, using JSON file: %v", err)
+	}
+	return storage.NewJSONContactStore(cfg.ContactsFile)
+}
+
+// newMatchStore construit le store de matchs, alimenté par l'API
+// football-data.org si elle est configurée, sinon par le fichier de matchs.
+func newMatchStore(cfg *config.Config) *models.MatchStore {
+	store := models.NewMatchStore(cfg.MatchesFile, cfg.CacheTTL)
+	if api := cfg.APIClient(); api != nil {
+		store.SetRemote(api.Matches)
+	}
+	return store
+}
+
+// newLive crée le hub des mises à jour en direct et lance le poller
+// qui relit les matchs toutes les `cfg.LiveInterval` : directement depuis
+// l'API si elle est configurée (le cache du store serait trop ancien),
+// sinon depuis `store`, qui recharge le fichier de matchs dès qu'il est
+// modifié.
+func newLive(cfg *config.Config, store *models.MatchStore) (*live.Hub, *live.Poller) {
+	hub := live.NewHub()
+	fetch := func(context.Context) ([]models.Match, error) { return store.All() }
+	if api := cfg.APIClient(); api != nil {
+		fetch = api.Matches
+	}
+	poller := live.NewPoller(hub, fetch, cfg.LiveInterval)
+	poller.Start()
+	// Le poller est arrêté avant le hub : plus aucune publication.
+	stoppers = append(stoppers, poller, hub)
+	return hub, poller
+}
+
+// overlayFS cherche chaque fichier dans `upper` puis, s'il n'y existe
+// pas, dans `lower`.
+type overlayFS struct {
+	upper, lower fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
 	}
-	return ""
+	return o.lower.Open(name)
 }
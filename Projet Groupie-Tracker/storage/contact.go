@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"groupie_tracker/models"
+)
+
+// ContactStore conserve les messages du formulaire de contact.
+// Deux implémentations existent : un fichier JSON (`JSONContactStore`)
+// et une table SQLite (`SQLiteContactStore`).
+type ContactStore interface {
+	// Save enregistre `msg` et le renvoie avec son ID et sa date
+	// (l'heure courante si `CreatedAt` est nulle).
+	Save(msg models.ContactMessage) (models.ContactMessage, error)
+	// List renvoie tous les messages, du plus récent au plus ancien.
+	List() ([]models.ContactMessage, error)
+}
+
+// JSONContactStore garde les messages dans un fichier JSON, réécrit en
+// entier (fichier temporaire puis renommage) à chaque nouveau message.
+type JSONContactStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewJSONContactStore crée un store adossé au fichier `path`, créé au
+// premier message.
+func NewJSONContactStore(path string) *JSONContactStore {
+	return &JSONContactStore{path: path}
+}
+
+// read lit les messages du fichier ; un fichier absent est une liste vide.
+func (s *JSONContactStore) read() ([]models.ContactMessage, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []models.ContactMessage{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var msgs []models.ContactMessage
+	if err := json.Unmarshal(b, &msgs); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return msgs, nil
+}
+
+func (s *JSONContactStore) Save(msg models.ContactMessage) (models.ContactMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgs, err := s.read()
+	if err != nil {
+		return models.ContactMessage{}, err
+	}
+	msg.ID = 1
+	for _, m := range msgs {
+		if m.ID >= msg.ID {
+			msg.ID = m.ID + 1
+		}
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+	b, err := json.MarshalIndent(append(msgs, msg), "", "  ")
+	if err != nil {
+		return models.ContactMessage{}, err
+	}
+	if err := writeFileAtomic(s.path, append(b, '\n')); err != nil {
+		return models.ContactMessage{}, err
+	}
+	return msg, nil
+}
+
+func (s *JSONContactStore) List() ([]models.ContactMessage, error) {
+	s.mu.Lock()
+	msgs, err := s.read()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].ID > msgs[j].ID })
+	return msgs, nil
+}
+
+// writeFileAtomic écrit `data` dans `path` via un fichier temporaire
+// renommé, pour qu'un lecteur ne voie jamais un fichier à moitié écrit.
+// Le répertoire est créé si nécessaire.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+const contactSchema = `
+CREATE TABLE IF NOT EXISTS contact_messages (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT NOT NULL,
+	email      TEXT NOT NULL,
+	message    TEXT NOT NULL,
+	created_at DATETIME NOT NULL
+);`
+
+// SQLiteContactStore garde les messages dans la table `contact_messages`.
+type SQLiteContactStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteContacts ouvre (ou crée) la base `path` et prépare la table
+// `contact_messages`.
+func OpenSQLiteContacts(path string) (*SQLiteContactStore, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(contactSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init contact schema: %w", err)
+	}
+	return &SQLiteContactStore{db: db}, nil
+}
+
+// Close ferme la base de données.
+func (s *SQLiteContactStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteContactStore) Save(msg models.ContactMessage) (models.ContactMessage, error) {
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+	}
+	res, err := s.db.Exec(`INSERT INTO contact_messages (name, email, message, created_at) VALUES (?, ?, ?, ?)`,
+		msg.Name, msg.Email, msg.Message, msg.CreatedAt)
+	if err != nil {
+		return models.ContactMessage{}, err
+	}
+	if msg.ID, err = res.LastInsertId(); err != nil {
+		return models.ContactMessage{}, err
+	}
+	return msg, nil
+}
+
+func (s *SQLiteContactStore) List() ([]models.ContactMessage, error) {
+	rows, err := s.db.Query(`SELECT id, name, email, message, created_at FROM contact_messages ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	msgs := []models.ContactMessage{}
+	for rows.Next() {
+		var m models.ContactMessage
+		if err := rows.Scan(&m.ID, &m.Name, &m.Email, &m.Message, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, rows.Err()
+}
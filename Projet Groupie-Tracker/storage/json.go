@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"sync"
+
+	"groupie_tracker/models"
+)
+
+// JSONRepository sert les clubs du fichier JSON via un `models.ClubStore`
+// (cache mémoire) ; les filtres et la pagination sont appliqués en mémoire.
+// Les modifications réécrivent le fichier entier, une à la fois.
+type JSONRepository struct {
+	Store *models.ClubStore
+	mu    sync.Mutex
+}
+
+// NewJSONRepository crée un dépôt adossé au store `store`.
+func NewJSONRepository(store *models.ClubStore) *JSONRepository {
+	return &JSONRepository{Store: store}
+}
+
+func (r *JSONRepository) All() ([]models.Club, error) {
+	return r.Store.All()
+}
+
+func (r *JSONRepository) ByID(id int) (models.Club, error) {
+	return r.Store.ByID(id)
+}
+
+func (r *JSONRepository) Search(q ClubQuery) ([]models.Club, int, error) {
+	clubs, err := r.Store.All()
+	if err != nil {
+		return nil, 0, err
+	}
+	filtered := []models.Club{}
+	for _, club := range clubs {
+		if q.Matches(club) {
+			filtered = append(filtered, club)
+		}
+	}
+	sortClubs(filtered, q)
+	return paginate(filtered, q.Offset, q.Limit), len(filtered), nil
+}
+
+func (r *JSONRepository) Countries() ([]string, error) {
+	clubs, err := r.Store.All()
+	if err != nil {
+		return nil, err
+	}
+	return models.Countries(clubs), nil
+}
+
+func (r *JSONRepository) Suggest(query string, limit int) ([]models.Suggestion, error) {
+	return r.Store.Suggest(query, limit)
+}
+
+func (r *JSONRepository) Create(club models.Club) (models.Club, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clubs, err := r.Store.All()
+	if err != nil {
+		return models.Club{}, err
+	}
+	if club.ID == 0 {
+		club.ID = nextClubID(clubs)
+	} else if _, err := models.GetClubByID(clubs, club.ID); err == nil {
+		return models.Club{}, models.ErrClubExists
+	}
+	if err := r.Store.Save(append(clubs, club)); err != nil {
+		return models.Club{}, err
+	}
+	return club, nil
+}
+
+func (r *JSONRepository) Update(club models.Club) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clubs, err := r.Store.All()
+	if err != nil {
+		return err
+	}
+	for i := range clubs {
+		if clubs[i].ID == club.ID {
+			clubs[i] = club
+			return r.Store.Save(clubs)
+		}
+	}
+	return models.ErrClubNotFound
+}
+
+func (r *JSONRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clubs, err := r.Store.All()
+	if err != nil {
+		return err
+	}
+	for i := range clubs {
+		if clubs[i].ID == id {
+			return r.Store.Save(append(clubs[:i], clubs[i+1:]...))
+		}
+	}
+	return models.ErrClubNotFound
+}
@@ -0,0 +1,372 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"groupie_tracker/models"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// clubsDriver est le pilote SQLite de la base des clubs : celui de
+// go-sqlite3, avec en plus la fonction SQL `go_lower` (strings.ToLower).
+// LOWER() de SQLite ne met en minuscules que les lettres ASCII ; les
+// recherches restent ainsi insensibles à la casse pour « Á » ou « Ö »,
+// comme avec le fichier JSON.
+const clubsDriver = "sqlite3_clubs"
+
+func init() {
+	sql.Register(clubsDriver, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("go_lower", strings.ToLower, true)
+		},
+	})
+}
+
+const clubSchema = `
+CREATE TABLE IF NOT EXISTS clubs (
+	id         INTEGER PRIMARY KEY,
+	name       TEXT NOT NULL,
+	short_name TEXT NOT NULL DEFAULT '',
+	tla        TEXT NOT NULL DEFAULT '',
+	website    TEXT NOT NULL DEFAULT '',
+	founded    INTEGER NOT NULL DEFAULT 0,
+	venue      TEXT NOT NULL DEFAULT '',
+	crest_url  TEXT NOT NULL DEFAULT '',
+	position   INTEGER NOT NULL DEFAULT 0
+);`
+
+// clubExtraColumns sont les colonnes ajoutées après la création de la
+// table : `migrate` les ajoute aux bases existantes.
+var clubExtraColumns = []string{"country", "city", "address", "colors", "competition"}
+
+const clubColumns = `id, name, short_name, tla, website, founded, venue, crest_url, country, city, address, colors, competition`
+
+// SQLiteRepository stocke les clubs dans une base SQLite. Les filtres
+// et la pagination de `Search` sont exécutés en SQL. L'index
+// d'autocomplétion est construit au premier appel de `Suggest` et
+// reconstruit après chaque modification des clubs.
+type SQLiteRepository struct {
+	db *sql.DB
+
+	mu    sync.Mutex
+	index *models.SuggestIndex
+	// version est incrémentée à chaque modification : un index construit
+	// à partir de clubs lus avant une modification n'est pas conservé.
+	version uint64
+}
+
+// OpenSQLite ouvre (ou crée) la base `path` et prépare la table `clubs`.
+func OpenSQLite(path string) (*SQLiteRepository, error) {
+	db, err := sql.Open(clubsDriver, path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(clubSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init clubs schema: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate clubs schema: %w", err)
+	}
+	return &SQLiteRepository{db: db}, nil
+}
+
+// migrate ajoute à la table `clubs` les colonnes de `clubExtraColumns`
+// absentes d'une base créée par une version précédente.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('clubs')`)
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, col := range clubExtraColumns {
+		if existing[col] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE clubs ADD COLUMN ` + col + ` TEXT NOT NULL DEFAULT ''`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close ferme la base de données.
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// Import remplace le contenu de la table `clubs` par `clubs`, dans une
+// transaction. L'ordre du fichier est conservé (colonne `position`).
+func (r *SQLiteRepository) Import(clubs []models.Club) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM clubs`); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(`INSERT INTO clubs (` + clubColumns + `, position) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i, c := range clubs {
+		if _, err := stmt.Exec(c.ID, c.Name, c.ShortName, c.TLA, c.Website, c.Founded, c.Venue, c.CrestURL,
+			c.Country, c.City, c.Address, c.Colors, c.Competition, i); err != nil {
+			return fmt.Errorf("club %d: %w", c.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.invalidate()
+	return nil
+}
+
+func (r *SQLiteRepository) Suggest(query string, limit int) ([]models.Suggestion, error) {
+	r.mu.Lock()
+	idx, version := r.index, r.version
+	r.mu.Unlock()
+	if idx == nil {
+		clubs, err := r.All()
+		if err != nil {
+			return nil, err
+		}
+		idx = models.NewSuggestIndex(clubs)
+		r.mu.Lock()
+		if r.version == version {
+			r.index = idx
+		}
+		r.mu.Unlock()
+	}
+	return idx.Suggest(query, limit), nil
+}
+
+// invalidate oublie l'index d'autocomplétion après une modification.
+func (r *SQLiteRepository) invalidate() {
+	r.mu.Lock()
+	r.index = nil
+	r.version++
+	r.mu.Unlock()
+}
+
+func (r *SQLiteRepository) Create(club models.Club) (models.Club, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Club{}, err
+	}
+	defer tx.Rollback()
+	if club.ID == 0 {
+		if err := tx.QueryRow(`SELECT COALESCE(MAX(id), 0) + 1 FROM clubs`).Scan(&club.ID); err != nil {
+			return models.Club{}, err
+		}
+	}
+	_, err = tx.Exec(`INSERT INTO clubs (`+clubColumns+`, position)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(MAX(position), -1) + 1 FROM clubs`,
+		club.ID, club.Name, club.ShortName, club.TLA, club.Website, club.Founded, club.Venue, club.CrestURL,
+		club.Country, club.City, club.Address, club.Colors, club.Competition)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return models.Club{}, models.ErrClubExists
+		}
+		return models.Club{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Club{}, err
+	}
+	r.invalidate()
+	return club, nil
+}
+
+func (r *SQLiteRepository) Update(club models.Club) error {
+	res, err := r.db.Exec(`UPDATE clubs SET name = ?, short_name = ?, tla = ?, website = ?, founded = ?, venue = ?, crest_url = ?,
+		country = ?, city = ?, address = ?, colors = ?, competition = ? WHERE id = ?`,
+		club.Name, club.ShortName, club.TLA, club.Website, club.Founded, club.Venue, club.CrestURL,
+		club.Country, club.City, club.Address, club.Colors, club.Competition, club.ID)
+	return r.changed(res, err)
+}
+
+func (r *SQLiteRepository) Delete(id int) error {
+	res, err := r.db.Exec(`DELETE FROM clubs WHERE id = ?`, id)
+	return r.changed(res, err)
+}
+
+// changed vérifie qu'une modification a touché une ligne, puis invalide
+// l'index d'autocomplétion.
+func (r *SQLiteRepository) changed(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrClubNotFound
+	}
+	r.invalidate()
+	return nil
+}
+
+// scanClubs lit les lignes renvoyées par une requête sur `clubColumns`.
+func scanClubs(rows *sql.Rows) ([]models.Club, error) {
+	defer rows.Close()
+	clubs := []models.Club{}
+	for rows.Next() {
+		var c models.Club
+		if err := rows.Scan(&c.ID, &c.Name, &c.ShortName, &c.TLA, &c.Website, &c.Founded, &c.Venue, &c.CrestURL,
+			&c.Country, &c.City, &c.Address, &c.Colors, &c.Competition); err != nil {
+			return nil, err
+		}
+		clubs = append(clubs, c)
+	}
+	return clubs, rows.Err()
+}
+
+func (r *SQLiteRepository) All() ([]models.Club, error) {
+	rows, err := r.db.Query(`SELECT ` + clubColumns + ` FROM clubs ORDER BY position, id`)
+	if err != nil {
+		return nil, err
+	}
+	return scanClubs(rows)
+}
+
+func (r *SQLiteRepository) ByID(id int) (models.Club, error) {
+	rows, err := r.db.Query(`SELECT `+clubColumns+` FROM clubs WHERE id = ?`, id)
+	if err != nil {
+		return models.Club{}, err
+	}
+	clubs, err := scanClubs(rows)
+	if err != nil {
+		return models.Club{}, err
+	}
+	if len(clubs) == 0 {
+		return models.Club{}, models.ErrClubNotFound
+	}
+	return clubs[0], nil
+}
+
+// likeEscaper échappe les jokers de LIKE dans un terme de recherche.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// whereClause traduit les filtres de `q` en clause WHERE et arguments.
+func whereClause(q ClubQuery) (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	if q.Search != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(q.Search)) + "%"
+		conds = append(conds, `(go_lower(name) LIKE ? ESCAPE '\' OR go_lower(short_name) LIKE ? ESCAPE '\' OR go_lower(tla) LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern)
+	}
+	if q.MinYear != 0 {
+		conds = append(conds, `founded >= ?`)
+		args = append(args, q.MinYear)
+	}
+	if q.MaxYear != 0 {
+		conds = append(conds, `founded <= ?`)
+		args = append(args, q.MaxYear)
+	}
+	if q.Country != "" {
+		conds = append(conds, `go_lower(country) = ?`)
+		args = append(args, strings.ToLower(q.Country))
+	}
+	if q.Venue != "" {
+		conds = append(conds, `go_lower(venue) LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(q.Venue))+"%")
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// orderClause traduit `q.Sort` et `q.Desc` en clause ORDER BY. Seuls les
+// critères de `ValidSort` sont reconnus, les autres gardent l'ordre d'import.
+// Les noms sont comparés avec `go_lower`, comme `sortClubs` compare les
+// `strings.ToLower` : COLLATE NOCASE ne replie que l'ASCII et classerait
+// autrement les noms accentués.
+func orderClause(q ClubQuery) string {
+	dir := " ASC"
+	if q.Desc {
+		dir = " DESC"
+	}
+	switch q.Sort {
+	case SortName:
+		return " ORDER BY go_lower(name)" + dir + ", position"
+	case SortShortName:
+		return " ORDER BY go_lower(short_name)" + dir + ", position"
+	case SortFounded:
+		return " ORDER BY founded" + dir + ", position"
+	}
+	return " ORDER BY position, id"
+}
+
+func (r *SQLiteRepository) Search(q ClubQuery) ([]models.Club, int, error) {
+	where, args := whereClause(q)
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM clubs`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = -1 // pas de limite pour SQLite
+	}
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	rows, err := r.db.Query(`SELECT `+clubColumns+` FROM clubs`+where+orderClause(q)+` LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	clubs, err := scanClubs(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return clubs, total, nil
+}
+
+func (r *SQLiteRepository) Countries() ([]string, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT country FROM clubs WHERE country != '' ORDER BY country`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	countries := []string{}
+	for rows.Next() {
+		var country string
+		if err := rows.Scan(&country); err != nil {
+			return nil, err
+		}
+		countries = append(countries, country)
+	}
+	return countries, rows.Err()
+}
+
+// Count renvoie le nombre de clubs en base.
+func (r *SQLiteRepository) Count() (int, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM clubs`).Scan(&n)
+	return n, err
+}
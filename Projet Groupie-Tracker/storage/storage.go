@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+
+	"groupie_tracker/models"
+)
+
+// ClubQuery décrit une recherche paginée de clubs.
+// Les champs vides ou nuls ne filtrent pas ; Limit <= 0 renvoie tous
+// les résultats à partir de Offset.
+type ClubQuery struct {
+	Search  string // sous-chaîne du nom, du nom court ou du TLA (insensible à la casse)
+	MinYear int    // année de fondation minimale
+	MaxYear int    // année de fondation maximale
+	Country string // pays exact (insensible à la casse)
+	Venue   string // sous-chaîne du stade (insensible à la casse)
+	Sort    string // SortName, SortShortName, SortFounded ou vide (ordre du fichier)
+	Desc    bool   // ordre décroissant
+	Offset  int
+	Limit   int
+}
+
+// Critères de tri acceptés par `ClubQuery.Sort` (noms des champs JSON).
+const (
+	SortName      = "name"
+	SortShortName = "shortName"
+	SortFounded   = "founded"
+)
+
+// ValidSort indique si `s` est un critère de tri accepté (vide compris).
+func ValidSort(s string) bool {
+	switch s {
+	case "", SortName, SortShortName, SortFounded:
+		return true
+	}
+	return false
+}
+
+// sortClubs trie `clubs` en place selon `q.Sort` et `q.Desc`. Le tri est
+// stable et insensible à la casse pour les noms ; sans critère, l'ordre
+// d'origine est conservé.
+func sortClubs(clubs []models.Club, q ClubQuery) {
+	var less func(a, b models.Club) bool
+	switch q.Sort {
+	case SortName:
+		less = func(a, b models.Club) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case SortShortName:
+		less = func(a, b models.Club) bool { return strings.ToLower(a.ShortName) < strings.ToLower(b.ShortName) }
+	case SortFounded:
+		less = func(a, b models.Club) bool { return a.Founded < b.Founded }
+	default:
+		return
+	}
+	sort.SliceStable(clubs, func(i, j int) bool {
+		if q.Desc {
+			return less(clubs[j], clubs[i])
+		}
+		return less(clubs[i], clubs[j])
+	})
+}
+
+// Matches indique si `club` satisfait les filtres de la requête
+// (la pagination n'est pas prise en compte).
+func (q ClubQuery) Matches(club models.Club) bool {
+	if q.Search != "" {
+		s := strings.ToLower(q.Search)
+		if !strings.Contains(strings.ToLower(club.Name), s) &&
+			!strings.Contains(strings.ToLower(club.ShortName), s) &&
+			!strings.Contains(strings.ToLower(club.TLA), s) {
+			return false
+		}
+	}
+	if q.MinYear != 0 && club.Founded < q.MinYear {
+		return false
+	}
+	if q.MaxYear != 0 && club.Founded > q.MaxYear {
+		return false
+	}
+	if q.Country != "" && !strings.EqualFold(club.Country, q.Country) {
+		return false
+	}
+	if q.Venue != "" && !strings.Contains(strings.ToLower(club.Venue), strings.ToLower(q.Venue)) {
+		return false
+	}
+	return true
+}
+
+// ClubRepository est la source des clubs utilisée par les handlers.
+// Deux implémentations existent : le fichier JSON (`JSONRepository`)
+// et une base SQLite (`SQLiteRepository`).
+type ClubRepository interface {
+	// All renvoie tous les clubs.
+	All() ([]models.Club, error)
+	// ByID renvoie le club `id` ou `models.ErrClubNotFound`.
+	ByID(id int) (models.Club, error)
+	// Search renvoie la page de clubs correspondant à `q` et le nombre
+	// total de clubs correspondants avant pagination.
+	Search(q ClubQuery) ([]models.Club, int, error)
+	// Countries renvoie les pays distincts des clubs, triés, pour
+	// proposer le filtre `Country`.
+	Countries() ([]string, error)
+	// Suggest renvoie au plus `limit` suggestions d'autocomplétion pour
+	// la saisie `query`, à partir d'un index construit à l'avance.
+	Suggest(query string, limit int) ([]models.Suggestion, error)
+	// Create enregistre un nouveau club et le renvoie. Un ID nul est
+	// remplacé par le plus grand ID existant + 1 ; un ID déjà pris
+	// renvoie `models.ErrClubExists`.
+	Create(club models.Club) (models.Club, error)
+	// Update remplace le club de même ID ou renvoie `models.ErrClubNotFound`.
+	Update(club models.Club) error
+	// Delete supprime le club `id` ou renvoie `models.ErrClubNotFound`.
+	Delete(id int) error
+}
+
+// nextClubID renvoie le plus grand ID de `clubs` + 1.
+func nextClubID(clubs []models.Club) int {
+	last := 0
+	for _, c := range clubs {
+		if c.ID > last {
+			last = c.ID
+		}
+	}
+	return last + 1
+}
+
+// paginate applique Offset et Limit à une liste déjà filtrée.
+func paginate(clubs []models.Club, offset, limit int) []models.Club {
+	total := len(clubs)
+	start := offset
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+	end := total
+	if limit > 0 && start+limit < total {
+		end = start + limit
+	}
+	return clubs[start:end]
+}